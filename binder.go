@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidFlagValue is returned when a flag value can not be converted to the type of its field
+var ErrInvalidFlagValue = errors.New("invalid flag value")
+
+// Binder derives command flags from a tagged options struct and fills fresh instances of the struct
+//
+// Supported tags:
+//
+//	cli:"verbose,v"		- long and short names, either may be empty; the long name (or the short one if there is none) is the flag Type
+//	help:"..."		- flag description
+//	default:"..."		- value used when the flag is absent
+//	env:"NAME"		- environment variable used when the flag is absent, takes precedence over default
+//
+// Fields without the cli tag are ignored, embedded structs are flattened.
+// Field types may be bool, string, signed and unsigned integers, floats, time.Duration,
+// []string (comma-separated) and types implementing encoding.TextUnmarshaler.
+type Binder struct {
+	LookupEnv func(key string) (string, bool) // os.LookupEnv by default
+
+	typ    reflect.Type
+	fields []binderField
+}
+
+type binderField struct {
+	index []int
+	flag  CommandFlag
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// NewBinder creates a Binder for the type of opts, which must be a struct or a pointer to a struct
+func NewBinder(opts any) (*Binder, error) {
+	typ := reflect.TypeOf(opts)
+	if typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("options must be a struct, got %v", typ)
+	}
+	b := &Binder{
+		LookupEnv: os.LookupEnv,
+		typ:       typ,
+	}
+	if err := b.collect(typ, nil); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Binder) collect(typ reflect.Type, index []int) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+		tag, ok := field.Tag.Lookup("cli")
+		if !ok {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := b.collect(field.Type, fieldIndex); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("field %s: tagged field must be exported", field.Name)
+		}
+		kind, err := fieldKind(field.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		long, short, _ := strings.Cut(tag, ",")
+		long, short = strings.TrimSpace(long), strings.TrimSpace(short)
+		if long == "" && short == "" {
+			return fmt.Errorf("field %s: tag must name the flag", field.Name)
+		}
+		typeName := long
+		if typeName == "" {
+			typeName = short
+		}
+		b.fields = append(b.fields, binderField{
+			index: fieldIndex,
+			flag: CommandFlag{
+				Type:    typeName,
+				Long:    long,
+				Short:   short,
+				Desc:    Description{Short: field.Tag.Get("help")},
+				Kind:    kind,
+				Default: field.Tag.Get("default"),
+				Env:     field.Tag.Get("env"),
+			},
+		})
+	}
+	return nil
+}
+
+func fieldKind(typ reflect.Type) (FlagKind, error) {
+	if reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		return KindString, nil
+	}
+	if typ == durationType {
+		return KindDuration, nil
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return KindBool, nil
+	case reflect.String:
+		return KindString, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return KindInt, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return KindUint, nil
+	case reflect.Float32, reflect.Float64:
+		return KindFloat, nil
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.String {
+			return KindStrings, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %v", typ)
+}
+
+// Flags returns command flags derived from the struct. Every call returns new copies.
+func (b *Binder) Flags() []*CommandFlag {
+	flags := make([]*CommandFlag, len(b.fields))
+	for i := range b.fields {
+		flag := b.fields[i].flag
+		flags[i] = &flag
+	}
+	return flags
+}
+
+// New returns a pointer to a fresh instance of the struct filled from flags, see Decode
+func (b *Binder) New(flags map[string]*ParsedCommandFlags) (any, error) {
+	dst := reflect.New(b.typ)
+	if err := b.decode(flags, dst.Elem()); err != nil {
+		return nil, err
+	}
+	return dst.Interface(), nil
+}
+
+// Decode fills dst, a pointer to the struct, from flags keyed by Type as passed to Command.Run.
+// Absent flags take the value of their environment variable, then their default, otherwise the field is left as is.
+func (b *Binder) Decode(flags map[string]*ParsedCommandFlags, dst any) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Type() != b.typ {
+		return fmt.Errorf("destination must be a non-nil *%v", b.typ)
+	}
+	return b.decode(flags, v.Elem())
+}
+
+func (b *Binder) decode(flags map[string]*ParsedCommandFlags, v reflect.Value) error {
+	for _, field := range b.fields {
+		flag := field.flag
+		value, present := "", false
+		if parsed, ok := flags[flag.Type]; ok {
+			value, present = parsed.Args, true
+			if value == "" {
+				if flag.Kind != KindBool {
+					return fmt.Errorf("%w: %s requires a value", ErrInvalidFlagValue, flag.display())
+				}
+				value = "true"
+			}
+		} else if env, ok := b.lookupEnv(flag.Env); ok {
+			value, present = env, true
+		} else if flag.Default != "" {
+			value, present = flag.Default, true
+		}
+		if !present {
+			continue
+		}
+		if err := setField(v.FieldByIndex(field.index), value); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidFlagValue, flag.display(), err)
+		}
+	}
+	return nil
+}
+
+func (b *Binder) lookupEnv(key string) (string, bool) {
+	if key == "" || b.LookupEnv == nil {
+		return "", false
+	}
+	return b.LookupEnv(key)
+}
+
+func setField(v reflect.Value, value string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		x, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		x, err := strconv.ParseInt(value, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(x)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		x, err := strconv.ParseUint(value, 0, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(x)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(value, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+	case reflect.Slice:
+		var items []string
+		if value != "" {
+			items = strings.Split(value, ",")
+		}
+		v.Set(reflect.ValueOf(items).Convert(v.Type()))
+	}
+	return nil
+}
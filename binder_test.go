@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type binderTestCommon struct {
+	Verbose bool `cli:"verbose,v" help:"verbose output"`
+}
+
+type binderTestOpts struct {
+	binderTestCommon
+	Name    string        `cli:"name,n" help:"user name" default:"guest"`
+	Port    int           `cli:"port" env:"TEST_PORT" default:"80"`
+	Timeout time.Duration `cli:",t" default:"1s"`
+	Tags    []string      `cli:"tags"`
+	Ignored string
+}
+
+func Test_Binder_Flags(t *testing.T) {
+	b, err := NewBinder(binderTestOpts{})
+	if err != nil {
+		t.Fatalf("NewBinder() error = %v", err)
+	}
+	want := []*CommandFlag{
+		{Type: "verbose", Long: "verbose", Short: "v", Desc: Description{Short: "verbose output"}, Kind: KindBool},
+		{Type: "name", Long: "name", Short: "n", Desc: Description{Short: "user name"}, Kind: KindString, Default: "guest"},
+		{Type: "port", Long: "port", Kind: KindInt, Default: "80", Env: "TEST_PORT"},
+		{Type: "t", Short: "t", Kind: KindDuration, Default: "1s"},
+		{Type: "tags", Long: "tags", Kind: KindStrings},
+	}
+	if got := b.Flags(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Binder.Flags() = %v, want %v", got, want)
+	}
+}
+
+func Test_Binder_New(t *testing.T) {
+	b, err := NewBinder(&binderTestOpts{})
+	if err != nil {
+		t.Fatalf("NewBinder() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		flags   map[string]*ParsedCommandFlags
+		env     map[string]string
+		want    *binderTestOpts
+		wantErr error
+	}{
+		{
+			name: "defaults",
+			flags: map[string]*ParsedCommandFlags{},
+			want: &binderTestOpts{Name: "guest", Port: 80, Timeout: time.Second},
+		},
+		{
+			name: "env overrides default",
+			flags: map[string]*ParsedCommandFlags{},
+			env: map[string]string{"TEST_PORT": "8080"},
+			want: &binderTestOpts{Name: "guest", Port: 8080, Timeout: time.Second},
+		},
+		{
+			name: "flags override env",
+			flags: map[string]*ParsedCommandFlags{
+				"verbose": {Type: "verbose", Name: "v"},
+				"port":    {Type: "port", Name: "port", Args: "9090"},
+				"t":       {Type: "t", Name: "t", Args: "1m"},
+				"tags":    {Type: "tags", Name: "tags", Args: "a,b"},
+			},
+			env: map[string]string{"TEST_PORT": "8080"},
+			want: &binderTestOpts{
+				binderTestCommon: binderTestCommon{Verbose: true},
+				Name: "guest",
+				Port: 9090,
+				Timeout: time.Minute,
+				Tags: []string{"a", "b"},
+			},
+		},
+		{
+			name: "invalid value",
+			flags: map[string]*ParsedCommandFlags{"port": {Type: "port", Name: "port", Args: "http"}},
+			wantErr: ErrInvalidFlagValue,
+		},
+		{
+			name: "missing value",
+			flags: map[string]*ParsedCommandFlags{"name": {Type: "name", Name: "name"}},
+			wantErr: ErrInvalidFlagValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b.LookupEnv = func(key string) (string, bool) {
+				v, ok := tt.env[key]
+				return v, ok
+			}
+			got, err := b.New(tt.flags)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Binder.New() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Binder.New() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_NewBinder_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts any
+	}{
+		{name: "not a struct", opts: 1},
+		{name: "unsupported type", opts: struct {
+			Ch chan int `cli:"ch"`
+		}{}},
+		{name: "unnamed flag", opts: struct {
+			Name string `cli:""`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewBinder(tt.opts); err == nil {
+				t.Errorf("NewBinder() must fail")
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package cli
+
+// builtinCommands returns commands available in every Cli.
+// Registered commands with the same name take precedence over them.
+func (c *cli) builtinCommands() []*Command {
+	return []*Command{
+		c.sourceCommand(),
+		c.setCommand(),
+		c.historyCommand(),
+		c.completionCommand(),
+		c.completeCommand(),
+		c.helpCommand(),
+	}
+}
@@ -1,55 +1,175 @@
 package cli
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"sync"
+)
 
 // Cli command processer
 type Cli interface {
-	OneCmd(input string) error // Process one command
-	AddCmd(commands ...*Command) // Adds one or more commands
+	OneCmd(input string) error // Process one command line
+	RunLine(ctx context.Context, line string) error // Process one command line of pipelines joined with ';', '&&' or '||'
+	Execute(ctx context.Context, argv []string) error // Process one command given as pre-split tokens
+	Main() // Runs the command given by os.Args and exits the process
+	RunScript(ctx context.Context, r io.Reader) error // Runs commands read from r line by line
+	Interact(ctx context.Context, prompt string) error // Runs command lines typed by the user until end of input
+	InteractWith(ctx context.Context, prompt string, run func(ctx context.Context, line string) error) error // Like Interact, runs lines with run instead of RunLine
+	Complete(line string) []string // Returns completion candidates for the last word of line
+	Schema() Schema // Returns a serializable description of registered commands
+	SetVar(name, value string) error // Sets a session variable expanded as $name in command lines
+	LookupVar(name string) (string, bool) // Returns a session variable
+	AddCmd(commands ...*Command) // Adds one or more commands, replacing commands with the same Use
+	Register(commands ...*Command) error // Adds one or more commands, rejecting duplicates and conflicting flags
+	ReplaceCmd(cmd *Command) error // Replaces a registered command with the same Use
+	RemoveCmd(path string) bool // Removes a command or a subcommand by path
+	Commands() []*Command // Returns registered commands sorted by Use, built-in commands are not included
+	Lookup(path string) *Command // Returns a registered or built-in command by path like 'db migrate'
+	OnInit(hooks ...func(ctx context.Context) error) // Adds hooks run once before the first command
+	OnShutdown(hooks ...func(ctx context.Context) error) // Adds hooks run by Shutdown
+	Shutdown(ctx context.Context) error // Runs shutdown hooks, should be called at exit
 }
 
 type cli struct {
 	cmds map[string]*Command
+	builtins map[string]*Command // dispatched when no registered command matches
+	reg sync.RWMutex // guards cmds
 	parser CommandParser
+	scriptPolicy ScriptPolicy
+	fsys fs.FS // opens files for '<' and source
+	fileWriter FileWriterFunc // opens files for '>', '>>', '2>' and '2>>'
+	vars map[string]string
+	varsMu sync.RWMutex
+	undefinedVars UndefinedVarPolicy
+	maxSubstDepth int
+	history *History // nil if disabled
+	name string // program name used by completion scripts
+	streams Streams // default streams of commands, see WithStdin
+	groups []CommandGroup // sections of commands in help, see WithGroups
+
+	mu sync.Mutex
+	initHooks []func(ctx context.Context) error
+	shutdownHooks []func(ctx context.Context) error
+	initialized int // number of init hooks that have completed
+	initDone chan struct{} // closed when running init hooks return, nil if they are not running
+	started bool // a command has been dispatched since the last shutdown
+	warned map[string]bool // deprecation warnings already printed
 }
 
-func NewCli() Cli {
-	return &cli{
+// Option configures a Cli
+type Option func(c *cli)
+
+func NewCli(opts ...Option) Cli {
+	c := &cli{
 		cmds: make(map[string]*Command),
+		builtins: make(map[string]*Command),
+		vars: make(map[string]string),
 		parser: NewCommandParser(),
+		fsys: osFS{},
+		fileWriter: writeOSFile,
+		maxSubstDepth: DefaultMaxSubstitutionDepth,
+	}
+	for _, cmd := range c.builtinCommands() {
+		c.builtins[cmd.Use] = cmd
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Process one command line of the form <command> [subcommands] <flags> <args>, see RunLine
+func (c *cli) OneCmd(input string) error {
+	return c.RunLine(context.Background(), input)
 }
 
-// Process one command of the form <command> <flags> <args>
-func (c cli) OneCmd(input string) error {
-	parsed, err := c.parser.ParseCommand(input)
+// Execute runs a command given as pre-split tokens of the form <command> [subcommands] <flags> <args>,
+// such as os.Args[1:]. Tokens are passed to the parser as is, without quote removal.
+func (c *cli) Execute(ctx context.Context, argv []string) error {
+	if len(argv) == 0 {
+		return usagef("empty input")
+	}
+	ctx = c.withStreams(ctx)
+	switch argv[0] {
+	case "--" + helpFlag, "-" + helpShortFlag:
+		return c.showHelp(StreamsFrom(ctx).Stdout, nil, helpFlag)
+	case "--" + helpJSONFlag:
+		return c.showHelp(StreamsFrom(ctx).Stdout, nil, helpJSONFlag)
+	}
+	path := c.resolvePath(argv)
+	if path == nil {
+		return usagef("command %s not found", argv[0])
+	}
+	cmd := path[len(path)-1]
+	parsed, err := c.parser.ParseTokens(argv[len(path)-1:])
 	if err != nil {
 		return err
 	}
-	cmd, ok := c.cmds[parsed.Name]
-	if !ok {
-		return fmt.Errorf("command %s not found", input)
+	if mode := helpMode(cmd, parsed); mode != "" {
+		return c.showHelp(StreamsFrom(ctx).Stdout, path, mode)
 	}
 	flags := make(map[string]*ParsedCommandFlags)
+	negated := make(map[string]bool) // types of flags given as '--no-<Long>'
 	for i := range parsed.Flags {
-		flag := cmd.GetFlag(i)
+		value := parsed.Flags[i].Args
+		flag, isNegated := cmd.GetFlag(i), false
 		if flag == nil {
-			continue
+			if flag = cmd.GetNegatedFlag(i); flag == nil {
+				continue
+			}
+			if value != "" {
+				return usagef("flag --%s does not take a value", i)
+			}
+			value, isNegated = "false", true
 		}
 		t := flag.Type
+		if _, ok := flags[t]; ok && negated[t] != isNegated {
+			return usagef("flags --%s and --%s%s cannot be used together", flag.Long, negationPrefix, flag.Long)
+		}
+		negated[t] = isNegated
+		if _, err := strconv.ParseBool(value); flag.Negatable && value != "" && err != nil {
+			return fmt.Errorf("%w: %s: %q is not a bool", ErrInvalidFlagValue, flag.display(), value)
+		}
 		flags[t] = &ParsedCommandFlags{
 			Type: t,
-			Args: parsed.Flags[i].Args,
+			Args: value,
 			Name: parsed.Flags[i].Name,
 		}
 	}
-	cmd.Run(flags, parsed.Args)
-	return nil
+	if !cmd.Runnable() {
+		return usagef("command %s requires a subcommand", commandPath(path))
+	}
+	if err := checkRequired(cmd, flags); err != nil {
+		return err
+	}
+	if err := checkFlagGroups(cmd, commandPath(path), flags); err != nil {
+		return err
+	}
+	if err := cmd.checkArgs(parsed.Args); err != nil {
+		return err
+	}
+	if err := checkValues(cmd, flags, parsed.Args); err != nil {
+		return err
+	}
+	var bound any
+	if cmd.Bind != nil {
+		if bound, err = cmd.Bind(flags); err != nil {
+			return err
+		}
+	}
+	c.warnDeprecated(StreamsFrom(ctx).Stderr, path, flags)
+	return c.runCommand(ctx, path, argv, flags, parsed.Args, bound)
 }
 
-// Adds one or more commands
-func (c cli) AddCmd(commands ...*Command) {
-	for _, cmd := range commands {
-		c.cmds[cmd.Use] = cmd
+// commandPath joins the names of the commands on path, e.g. 'db migrate'
+func commandPath(path []*Command) string {
+	names := make([]string, len(path))
+	for i, cmd := range path {
+		names[i] = cmd.Use
 	}
-}
\ No newline at end of file
+	return strings.Join(names, " ")
+}
@@ -1,10 +1,21 @@
 package cli
 
 import (
+	"context"
+	"errors"
+	"reflect"
 	"strings"
 	"testing"
 )
 
+// errText returns the message of err, or "" if err is nil, for comparing errors with wantErr strings of test tables
+func errText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
 func Test_cli_OneCmd(t *testing.T) {
 	cli := NewCli()
 	cli.AddCmd(
@@ -673,3 +684,60 @@ func Test_cli_OneCmd(t *testing.T) {
 		})
 	}
 }
+
+func Test_cli_negatableFlags(t *testing.T) {
+	var got *Invocation
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use: "show",
+		Flags: []*CommandFlag{
+			{Type: "color", Long: "color", Short: "c", Kind: KindBool, Default: "true", Negatable: true},
+			{Type: "pager", Long: "pager", Kind: KindBool},
+		},
+		Handle: func(inv *Invocation) error {
+			got = inv
+			return nil
+		},
+	})
+	tests := []struct {
+		line    string
+		want    bool
+		wantErr error
+	}{
+		{line: "show", want: true},
+		{line: "show --color", want: true},
+		{line: "show -c", want: true},
+		{line: "show --no-color", want: false},
+		{line: "show --color=false", want: false},
+		{line: "show --color=true", want: true},
+		{line: "show --color=maybe", wantErr: ErrInvalidFlagValue},
+		{line: "show --no-color=false", wantErr: ErrUsage},
+		{line: "show --color --no-color", wantErr: ErrUsage},
+		{line: "show -c --no-color", wantErr: ErrUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got = nil
+			err := c.OneCmd(tt.line)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.OneCmd() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.Bool("color") != tt.want {
+				t.Errorf("color = %v, want %v", got.Bool("color"), tt.want)
+			}
+		})
+	}
+	if err := c.OneCmd("show --no-pager"); err != nil || got.Bool("pager") || len(got.Flags) != 0 {
+		t.Errorf("--no-pager of a flag that is not negatable must be ignored, error = %v, flags = %v", err, got.Flags)
+	}
+	if want := []string{"--color", "--no-color"}; !reflect.DeepEqual(c.Complete("show --c"), want[:1]) || !reflect.DeepEqual(c.Complete("show --no"), want[1:]) {
+		t.Errorf("cli.Complete() = %q, %q, want %q", c.Complete("show --c"), c.Complete("show --no"), want)
+	}
+	var out strings.Builder
+	if err := c.RunLine(ContextWithStreams(context.Background(), Streams{Stdout: &out}), "show --help"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  -c, --[no-]color   (default true)\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("help output does not contain %q:\n%s", want, out.String())
+	}
+}
@@ -0,0 +1,232 @@
+// Package clitest runs command lines and scripts against a cli.Cli in tests,
+// capturing their output, errors and the commands that ran.
+//
+//	res := clitest.Run(t, c, "db migrate --to=3")
+//	res.AssertSuccess(t)
+//	res.AssertInvoked(t, "db migrate")
+//	res.AssertGolden(t, "migrate")
+//
+// Golden files are read from testdata/<name>.golden and rewritten by running tests with -update.
+// clitest does not register the flag, so it does not clash with flags of the tested package;
+// declare it in the test package:
+//
+//	var _ = flag.Bool("update", false, "rewrite golden files")
+package clitest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/AlexandrShapkin/cli"
+	"github.com/AlexandrShapkin/cli/internal/syncbuf"
+	"github.com/AlexandrShapkin/cli/transcript"
+)
+
+// UpdateFlag is the name of the boolean flag that makes AssertGolden rewrite golden files
+var UpdateFlag = "update"
+
+// updating reports whether UpdateFlag is declared and set
+func updating() bool {
+	f := flag.Lookup(UpdateFlag)
+	if f == nil {
+		return false
+	}
+	on, _ := strconv.ParseBool(f.Value.String())
+	return on
+}
+
+// Result is the outcome of a command line or a script
+type Result struct {
+	Stdout   string
+	Stderr   string
+	Err      error
+	ExitCode int      // cli.ExitCode of Err
+	Invoked  []string // paths of commands whose handlers ran, e.g. 'db migrate', in order
+}
+
+// Option configures a run
+type Option func(o *options)
+
+type options struct {
+	stdin string
+	ctx   context.Context
+}
+
+// Stdin sets the standard input of the run, empty by default
+func Stdin(s string) Option {
+	return func(o *options) {
+		o.stdin = s
+	}
+}
+
+// Context sets the context of the run, context.Background() by default
+func Context(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// Run runs a command line with c.RunLine
+func Run(t testing.TB, c cli.Cli, line string, opts ...Option) *Result {
+	t.Helper()
+	return run(opts, func(ctx context.Context) error {
+		return c.RunLine(ctx, line)
+	})
+}
+
+// Execute runs a command given as pre-split tokens with c.Execute
+func Execute(t testing.TB, c cli.Cli, argv []string, opts ...Option) *Result {
+	t.Helper()
+	return run(opts, func(ctx context.Context) error {
+		return c.Execute(ctx, argv)
+	})
+}
+
+// RunScript runs a script with c.RunScript
+func RunScript(t testing.TB, c cli.Cli, script string, opts ...Option) *Result {
+	t.Helper()
+	return run(opts, func(ctx context.Context) error {
+		return c.RunScript(ctx, strings.NewReader(script))
+	})
+}
+
+// Replay replays a transcript file recorded with transcript.Recorder against c, reporting every difference.
+// Of the options only Context applies, command lines are run with empty stdin.
+func Replay(t testing.TB, c cli.Cli, path string, opts ...Option) {
+	t.Helper()
+	o := options{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	diffs, err := transcript.ReplayFile(o.ctx, c, path)
+	if err != nil {
+		t.Fatalf("replaying transcript: %v", err)
+		return
+	}
+	for _, d := range diffs {
+		t.Errorf("%s", d)
+	}
+}
+
+func run(opts []Option, fn func(ctx context.Context) error) *Result {
+	o := options{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var stdout, stderr syncbuf.Builder
+	res := &Result{}
+	var mu sync.Mutex
+	ctx := cli.ContextWithStreams(o.ctx, cli.Streams{
+		Stdin:  strings.NewReader(o.stdin),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	ctx = cli.ContextWithObserver(ctx, func(inv *cli.Invocation) {
+		names := make([]string, len(inv.Path))
+		for i, cmd := range inv.Path {
+			names[i] = cmd.Use
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		res.Invoked = append(res.Invoked, strings.Join(names, " "))
+	})
+	res.Err = fn(ctx)
+	res.ExitCode = cli.ExitCode(res.Err)
+	res.Stdout, res.Stderr = stdout.String(), stderr.String()
+	return res
+}
+
+// AssertSuccess fails the test if the run returned an error
+func (r *Result) AssertSuccess(t testing.TB) {
+	t.Helper()
+	if r.Err != nil {
+		t.Errorf("unexpected error: %v\nstderr: %s", r.Err, r.Stderr)
+	}
+}
+
+// AssertError fails the test unless the run returned an error matching target with errors.Is.
+// A nil target accepts any error.
+func (r *Result) AssertError(t testing.TB, target error) {
+	t.Helper()
+	switch {
+	case r.Err == nil:
+		t.Errorf("expected an error, got none")
+	case target != nil && !errors.Is(r.Err, target):
+		t.Errorf("error = %v, want %v", r.Err, target)
+	}
+}
+
+// AssertExitCode fails the test if the exit code of the run differs from code
+func (r *Result) AssertExitCode(t testing.TB, code int) {
+	t.Helper()
+	if r.ExitCode != code {
+		t.Errorf("exit code = %d, want %d (error: %v)", r.ExitCode, code, r.Err)
+	}
+}
+
+// AssertStdout fails the test if stdout differs from want
+func (r *Result) AssertStdout(t testing.TB, want string) {
+	t.Helper()
+	if r.Stdout != want {
+		t.Errorf("stdout = %q, want %q", r.Stdout, want)
+	}
+}
+
+// AssertStderr fails the test if stderr differs from want
+func (r *Result) AssertStderr(t testing.TB, want string) {
+	t.Helper()
+	if r.Stderr != want {
+		t.Errorf("stderr = %q, want %q", r.Stderr, want)
+	}
+}
+
+// AssertInvoked fails the test unless handlers of all commands given by path, like 'db migrate', ran
+func (r *Result) AssertInvoked(t testing.TB, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if !slices.Contains(r.Invoked, path) {
+			t.Errorf("command %q was not invoked, invoked: %q", path, r.Invoked)
+		}
+	}
+}
+
+// AssertNotInvoked fails the test if a handler of any command given by path ran
+func (r *Result) AssertNotInvoked(t testing.TB, paths ...string) {
+	t.Helper()
+	for _, path := range paths {
+		if slices.Contains(r.Invoked, path) {
+			t.Errorf("command %q was invoked", path)
+		}
+	}
+}
+
+// AssertGolden compares stdout with testdata/<name>.golden. With -update the file is rewritten instead.
+func (r *Result) AssertGolden(t testing.TB, name string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(r.Stdout), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file: %v (run the test with -update to create it)", err)
+		return
+	}
+	if r.Stdout != string(want) {
+		t.Errorf("stdout differs from %s:\ngot:\n%s\nwant:\n%s", path, r.Stdout, want)
+	}
+}
@@ -0,0 +1,176 @@
+package clitest
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+// update is declared by the test package as clitest does not register it
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// recorder is a testing.TB recording failures instead of reporting them
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func testCli() cli.Cli {
+	c := cli.NewCli()
+	c.AddCmd(
+		&cli.Command{
+			Use: "db",
+			Subcommands: []*cli.Command{{
+				Use: "migrate",
+				Handle: func(inv *cli.Invocation) error {
+					fmt.Fprintf(inv.Stdout, "migrated %s\n", strings.Join(inv.Args, " "))
+					fmt.Fprintln(inv.Stderr, "done")
+					return nil
+				},
+			}},
+		},
+		&cli.Command{
+			Use: "upper",
+			Handle: func(inv *cli.Invocation) error {
+				data, err := io.ReadAll(inv.Stdin)
+				if err != nil {
+					return err
+				}
+				_, err = inv.Stdout.Write([]byte(strings.ToUpper(string(data))))
+				return err
+			},
+		},
+		&cli.Command{
+			Use: "fail",
+			Handle: func(inv *cli.Invocation) error {
+				return cli.Exit(3, errors.New("failed"))
+			},
+		},
+	)
+	return c
+}
+
+func TestRun(t *testing.T) {
+	c := testCli()
+	res := Run(t, c, "db migrate 1 2 | upper && fail || db migrate 3")
+	res.AssertSuccess(t)
+	res.AssertExitCode(t, cli.ExitOK)
+	res.AssertStdout(t, "MIGRATED 1 2\nmigrated 3\n")
+	res.AssertStderr(t, "done\ndone\n")
+	res.AssertInvoked(t, "db migrate", "upper", "fail")
+	if want := []string{"db migrate", "upper", "fail", "db migrate"}; len(res.Invoked) != 4 {
+		t.Errorf("Result.Invoked = %q, want %q in any order of the pipeline", res.Invoked, want)
+	}
+
+	res = Run(t, c, "upper", Stdin("abc"))
+	res.AssertStdout(t, "ABC")
+
+	res = Execute(t, c, []string{"fail"})
+	res.AssertError(t, nil)
+	res.AssertExitCode(t, 3)
+	res.AssertNotInvoked(t, "db migrate")
+
+	res = RunScript(t, c, "db migrate 1\n# comment\nnope\n")
+	res.AssertError(t, cli.ErrUsage)
+	res.AssertExitCode(t, cli.ExitUsage)
+	if !reflect.DeepEqual(res.Invoked, []string{"db migrate"}) {
+		t.Errorf("Result.Invoked = %q", res.Invoked)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res = Run(t, c, "db migrate", Context(ctx))
+	res.AssertExitCode(t, cli.ExitInterrupted)
+}
+
+func TestResult_assertionsFail(t *testing.T) {
+	res := &Result{Stdout: "out", Stderr: "err", Err: errors.New("boom"), ExitCode: 1, Invoked: []string{"a"}}
+	rec := &recorder{TB: t}
+	res.AssertSuccess(rec)
+	res.AssertError(rec, cli.ErrUsage)
+	res.AssertExitCode(rec, 0)
+	res.AssertStdout(rec, "other")
+	res.AssertStderr(rec, "other")
+	res.AssertInvoked(rec, "b")
+	res.AssertNotInvoked(rec, "a")
+	(&Result{}).AssertError(rec, nil)
+	if len(rec.failures) != 8 {
+		t.Errorf("failures = %d, want 8:\n%s", len(rec.failures), strings.Join(rec.failures, "\n"))
+	}
+}
+
+func TestResult_AssertGolden(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	defer func(v bool) { *update = v }(*update)
+
+	res := &Result{Stdout: "line 1\nline 2\n"}
+	rec := &recorder{TB: t}
+	*update = false
+	res.AssertGolden(rec, "missing")
+	if len(rec.failures) != 1 {
+		t.Fatalf("AssertGolden() without a golden file failures = %q, want one", rec.failures)
+	}
+
+	*update = true
+	res.AssertGolden(t, "sub/out")
+	*update = false
+	res.AssertGolden(t, "sub/out")
+
+	rec = &recorder{TB: t}
+	(&Result{Stdout: "changed\n"}).AssertGolden(rec, "sub/out")
+	if len(rec.failures) != 1 {
+		t.Errorf("AssertGolden() with different output failures = %q, want one", rec.failures)
+	}
+
+	defer func(name string) { UpdateFlag = name }(UpdateFlag)
+	UpdateFlag = "undeclared"
+	*update = true
+	rec = &recorder{TB: t}
+	res.AssertGolden(rec, "undeclared")
+	if len(rec.failures) != 1 {
+		t.Errorf("AssertGolden() with an undeclared UpdateFlag failures = %q, want one", rec.failures)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	Replay(t, testCli(), "testdata/session.txt")
+
+	rec := &recorder{TB: t}
+	c := testCli()
+	c.AddCmd(&cli.Command{Use: "fail", Handle: func(inv *cli.Invocation) error { return nil }})
+	Replay(rec, c, "testdata/session.txt")
+	if len(rec.failures) != 2 {
+		t.Errorf("Replay() failures = %q, want error and exit code diffs of 'fail'", rec.failures)
+	}
+
+	rec = &recorder{TB: t}
+	Replay(rec, c, "testdata/missing.txt")
+	if len(rec.failures) != 1 {
+		t.Errorf("Replay() of a missing file failures = %q, want one", rec.failures)
+	}
+}
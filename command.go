@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"strings"
 )
 
@@ -10,12 +11,84 @@ type Description struct {
 	Short string
 }
 
+// HookFunc is run before or after a command handler. A non-nil error stops the execution chain
+type HookFunc func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error
+
 // Command structure representing a command
 type Command struct {
-	Use   string
+	Use     string
+	Aliases []string // alternative names of the command
 	Flags []*CommandFlag
+	FlagGroups []*FlagGroup // rules over Flags checked before the command runs
+	Args  []*CommandArg // positional arguments, unchecked if empty
 	Desc  Description
+	Example string // usage examples, one per line, shown in generated docs
+	Deprecated *Deprecation // prints a warning when the command is used
+	Hidden bool // dispatched, but not shown in help, completion and docs
+	Group string // ID of a CommandGroup the command is listed under in help
 	Run   func(flags map[string]*ParsedCommandFlags, args []string)
+	RunE  func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error // takes precedence over Run
+	Handle func(inv *Invocation) error // takes precedence over RunE and Run
+	Bind func(flags map[string]*ParsedCommandFlags) (any, error) // converts flags before any hook runs, the result is Invocation.Bound
+
+	Subcommands []*Command // selected by the words following Use, e.g. 'db migrate'
+
+	PersistentPreRun  HookFunc // runs before PreRun of this command and of all its subcommands
+	PreRun            HookFunc // runs before Run or RunE
+	PostRun           HookFunc // runs after Run or RunE
+	PersistentPostRun HookFunc // runs after PostRun of this command and of all its subcommands
+}
+
+// Reports whether the command has a handler, otherwise it only groups subcommands
+func (c *Command) Runnable() bool {
+	return c.Handle != nil || c.RunE != nil || c.Run != nil
+}
+
+// Returns a subcommand by name or alias. If it does not exist, then nil.
+func (c *Command) GetSubcommand(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.HasName(name) {
+			return sub
+		}
+	}
+	return nil
+}
+
+// VisibleSubcommands returns subcommands that are not Hidden
+func (c *Command) VisibleSubcommands() []*Command {
+	var subs []*Command
+	for _, sub := range c.Subcommands {
+		if !sub.Hidden {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// VisibleFlags returns flags that are not Hidden
+func (c *Command) VisibleFlags() []*CommandFlag {
+	var flags []*CommandFlag
+	for _, f := range c.Flags {
+		if !f.Hidden {
+			flags = append(flags, f)
+		}
+	}
+	return flags
+}
+
+// Returns Use followed by aliases
+func (c *Command) Names() []string {
+	return append([]string{c.Use}, c.Aliases...)
+}
+
+// Reports whether name is Use or one of aliases
+func (c *Command) HasName(name string) bool {
+	for _, n := range c.Names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
 }
 
 // Returns a flag by type name. Returns a flag by type name. If it does not exist, then nil.
@@ -33,12 +106,116 @@ func (c *Command) GetFlag(flag string) *CommandFlag {
 	return nil
 }
 
+// FlagKind describes a value taken by a flag
+type FlagKind string
+
+const (
+	KindBool     FlagKind = "bool"     // '--flag' or '--flag=false', also '--no-flag' if Negatable
+	KindString   FlagKind = "string"   // '--flag=value'
+	KindInt      FlagKind = "int"      // '--flag=-1'
+	KindUint     FlagKind = "uint"     // '--flag=1'
+	KindFloat    FlagKind = "float"    // '--flag=1.5'
+	KindDuration FlagKind = "duration" // '--flag=1m30s'
+	KindStrings  FlagKind = "strings"  // '--flag=a,b,c'
+)
+
 // CommandFlag structure representing flag for command
 type CommandFlag struct {
-	Type  string // flag id
-	Long  string // for '--flag'
-	Short string // for '-f'
-	Desc  Description
+	Type    string // flag id
+	Long    string // for '--flag'
+	Short   string // for '-f'
+	Desc    Description
+	Kind    FlagKind // value kind, empty if unspecified
+	Default string   // value used when the flag is absent
+	Env     string   // environment variable used when the flag is absent, takes precedence over Default
+	Required bool    // the flag must be given unless Env or Default provides a value
+	Choices  []string  // allowed values, each element for KindStrings, shown in help and completed
+	Validate Validator // checks given values and values of Env
+	Deprecated *Deprecation // prints a warning when the flag is given
+	Hidden     bool         // accepted, but not shown in help, completion and docs
+	Negatable  bool         // a bool flag with Long also accepted as '--no-<Long>' meaning false
+}
+
+// CommandArg describes a positional argument of a command
+type CommandArg struct {
+	Name     string
+	Desc     Description
+	Required bool
+	Variadic bool // takes all remaining arguments, only the last argument may be variadic
+	Choices  []string  // allowed values, shown in help and completed
+	Validate Validator // checks given values
+}
+
+// usage returns the argument as it is shown in usage lines, e.g. '<file>' or '[files...]'
+func (a *CommandArg) usage() string {
+	name := a.Name
+	if a.Variadic {
+		name += "..."
+	}
+	if a.Required {
+		return "<" + name + ">"
+	}
+	return "[" + name + "]"
+}
+
+// ArgsUsage returns positional arguments as they are shown in usage lines, e.g. '<src> [dst...]',
+// or '[args]' if the command declares no arguments
+func (c *Command) ArgsUsage() string {
+	if len(c.Args) == 0 {
+		return "[args]"
+	}
+	names := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		names[i] = arg.usage()
+	}
+	return strings.Join(names, " ")
+}
+
+// checkArgs validates the number of positional arguments against Args
+func (c *Command) checkArgs(args []string) error {
+	if len(c.Args) == 0 {
+		return nil
+	}
+	required := 0
+	for _, arg := range c.Args {
+		if arg.Required {
+			required++
+		}
+	}
+	last := c.Args[len(c.Args)-1]
+	switch {
+	case len(args) < required:
+		return usagef("missing argument %s", c.Args[len(args)].usage())
+	case len(args) > len(c.Args) && !last.Variadic:
+		return usagef("too many arguments, expected %s", c.ArgsUsage())
+	}
+	return nil
+}
+
+// negationPrefix is prepended to Long of Negatable flags
+const negationPrefix = "no-"
+
+// GetNegatedFlag returns a Negatable flag whose negated name is flag, e.g. 'color' for 'no-color'.
+// If it does not exist, then nil.
+func (c *Command) GetNegatedFlag(flag string) *CommandFlag {
+	long, ok := strings.CutPrefix(flag, negationPrefix)
+	if !ok {
+		return nil
+	}
+	for _, f := range c.Flags {
+		if f.Negatable && f.Long != "" && f.Long == long {
+			return f
+		}
+	}
+	return nil
+}
+
+// display returns the flag as it is written in input, e.g. '--flag' or '-f'
+func (f *CommandFlag) display() string {
+	if f.Long != "" {
+		return "--" + f.Long
+	}
+	return "-" + f.Short
 }
 
 type ParsedCommand struct {
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Complete returns sorted candidates for the last word of line: command names, subcommand names,
+// flags like '--flag' and '-f' of the command being typed, Choices of a flag value given
+// as '--flag=value' or Choices of a positional argument. If line ends with a space, the last word is empty.
+// Only the command after the last ';', '|' or '&' is considered.
+func (c *cli) Complete(line string) []string {
+	if i := strings.LastIndexAny(line, ";|&"); i >= 0 {
+		line = line[i+1:]
+	}
+	words := strings.Fields(line)
+	if len(words) == 0 || strings.TrimRightFunc(line, unicode.IsSpace) != line {
+		words = append(words, "")
+	}
+	return c.completeWords(words)
+}
+
+// completeWords returns candidates for the last word of words, which are the words of a command typed so far.
+// Like the parser, it treats every word after the first argument or '--' as an argument.
+func (c *cli) completeWords(words []string) []string {
+	prefix := words[len(words)-1]
+	if len(words) == 1 {
+		return matchPrefix(c.commandNames(), prefix)
+	}
+	path := c.resolvePath(words[:len(words)-1])
+	if path == nil {
+		return nil
+	}
+	cmd := path[len(path)-1]
+	subcommands := len(path) == len(words)-1 // no argument has been typed yet, so a subcommand may follow
+	flags := true                            // no argument or '--' has been typed yet, so a flag may follow
+	args := 0
+	for _, word := range words[len(path) : len(words)-1] {
+		switch {
+		case word == "--":
+			flags = false
+		case flags && strings.HasPrefix(word, "-"):
+		default:
+			flags = false
+			args++
+		}
+	}
+	if flags && strings.HasPrefix(prefix, "-") {
+		if name, v, ok := strings.Cut(prefix, "="); ok {
+			flag := cmd.GetFlag(strings.TrimPrefix(name, "--"))
+			if flag == nil || !strings.HasPrefix(name, "--") {
+				return nil
+			}
+			return matchPrefix(prefixed(name+"=", flag.Choices), name+"="+v)
+		}
+		return matchPrefix(flagNames(cmd), prefix)
+	}
+	var names []string
+	if subcommands {
+		c.reg.RLock()
+		for _, sub := range cmd.VisibleSubcommands() {
+			names = append(names, sub.Use)
+		}
+		c.reg.RUnlock()
+	}
+	if len(cmd.Args) > 0 {
+		arg := cmd.Args[min(args, len(cmd.Args)-1)]
+		if args < len(cmd.Args) || arg.Variadic {
+			names = append(names, arg.Choices...)
+		}
+	}
+	return matchPrefix(names, prefix)
+}
+
+// prefixed returns names with prefix prepended
+func prefixed(prefix string, names []string) []string {
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = prefix + name
+	}
+	return result
+}
+
+// commandNames returns names of registered and built-in commands, except hidden ones and internal ones starting with "__"
+func (c *cli) commandNames() []string {
+	c.reg.RLock()
+	defer c.reg.RUnlock()
+	var names []string
+	for name, cmd := range c.cmds {
+		if !cmd.Hidden {
+			names = append(names, name)
+		}
+	}
+	for name := range c.builtins {
+		if _, ok := c.cmds[name]; !ok && !strings.HasPrefix(name, "__") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// flagNames returns flags of cmd as they are written in input, except hidden ones
+func flagNames(cmd *Command) []string {
+	var names []string
+	for _, f := range cmd.VisibleFlags() {
+		if f.Long != "" {
+			names = append(names, "--"+f.Long)
+		}
+		if f.Long != "" && f.Negatable {
+			names = append(names, "--"+negationPrefix+f.Long)
+		}
+		if f.Short != "" {
+			names = append(names, "-"+f.Short)
+		}
+	}
+	return names
+}
+
+// matchPrefix returns sorted names starting with prefix
+func matchPrefix(names []string, prefix string) []string {
+	var matches []string
+	for _, name := range names {
+		if strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
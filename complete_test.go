@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_cli_Complete(t *testing.T) {
+	c := NewCli()
+	c.AddCmd(
+		&Command{
+			Use: "db",
+			Subcommands: []*Command{
+				{Use: "migrate", Aliases: []string{"m"}, Run: func(flags map[string]*ParsedCommandFlags, args []string) {},
+					Flags: []*CommandFlag{
+						{Type: "dry", Long: "dry-run", Short: "n", Kind: KindBool},
+						{Type: "to", Long: "to", Kind: KindString},
+					}},
+				{Use: "status", Run: func(flags map[string]*ParsedCommandFlags, args []string) {}},
+			},
+		},
+		&Command{Use: "deploy", Run: func(flags map[string]*ParsedCommandFlags, args []string) {},
+			Flags: []*CommandFlag{{Type: "format", Long: "format", Short: "f", Kind: KindString, Choices: []string{"json", "table", "text"}}},
+			Args: []*CommandArg{
+				{Name: "env", Choices: []string{"dev", "prod"}},
+				{Name: "regions", Variadic: true, Choices: []string{"eu", "us"}},
+			}},
+		&Command{Use: "set", Run: func(flags map[string]*ParsedCommandFlags, args []string) {}},
+	)
+	tests := []struct {
+		name string
+		line string
+		want []string
+	}{
+		{name: "all commands", line: "", want: []string{"completion", "db", "deploy", "help", "history", "set", "source"}},
+		{name: "command prefix", line: "d", want: []string{"db", "deploy"}},
+		{name: "subcommands", line: "db ", want: []string{"migrate", "status"}},
+		{name: "subcommand prefix", line: "db st", want: []string{"status"}},
+		{name: "flags", line: "db migrate -", want: []string{"--dry-run", "--to", "-n"}},
+		{name: "flags by alias", line: "db m --d", want: []string{"--dry-run"}},
+		{name: "no subcommands after arguments", line: "db migrate x ", want: nil},
+		{name: "flag value", line: "db migrate --to ", want: nil},
+		{name: "after bool flag", line: "db migrate --dry-run -", want: []string{"--dry-run", "--to", "-n"}},
+		{name: "no flag choices after space", line: "deploy --format ", want: []string{"dev", "prod"}},
+		{name: "no short flag choices", line: "deploy -f=t", want: nil},
+		{name: "flag choices after '='", line: "deploy --format=t", want: []string{"--format=table", "--format=text"}},
+		{name: "argument choices", line: "deploy --format=json ", want: []string{"dev", "prod"}},
+		{name: "no flags after arguments", line: "deploy dev -", want: nil},
+		{name: "arguments after '--'", line: "deploy -- ", want: []string{"dev", "prod"}},
+		{name: "variadic argument choices", line: "deploy dev eu ", want: []string{"eu", "us"}},
+		{name: "after operator", line: "deploy && db s", want: []string{"status"}},
+		{name: "unknown command", line: "nope -", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Complete(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cli.Complete(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
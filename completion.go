@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// completeName is the name of the built-in command called back by shell completion scripts
+const completeName = "__complete"
+
+// Sets the program name used by shell completion scripts, the base name of os.Args[0] by default
+func WithName(name string) Option {
+	return func(c *cli) {
+		c.name = name
+	}
+}
+
+// completionScripts are templates of shell completion scripts. They call '<name> __complete -- <words>'
+// with the words typed after the program name, the last one being the word under the cursor.
+//
+// Bash splits COMP_WORDS at COMP_WORDBREAKS such as '=', so the bash script splits COMP_LINE at spaces instead
+// and strips the part of candidates before the word bash replaces, e.g. '--format=' of '--format=json'.
+var completionScripts = map[string]*template.Template{
+	"bash": template.Must(template.New("bash").Parse(`# bash completion for {{.Name}}
+_{{.Func}}_complete() {
+    local line=${COMP_LINE:0:COMP_POINT} words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+    local cur=${words[${#words[@]}-1]}
+    local prefix=${cur%"${COMP_WORDS[COMP_CWORD]}"}
+    local IFS=$'\n'
+    COMPREPLY=($({{.Name}} ` + completeName + ` -- "${words[@]:1}" 2>/dev/null))
+    COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+}
+complete -o default -F _{{.Func}}_complete {{.Name}}
+`)),
+	"zsh": template.Must(template.New("zsh").Parse(`#compdef {{.Name}}
+# zsh completion for {{.Name}}
+_{{.Func}}_complete() {
+    local -a candidates
+    candidates=(${(f)"$({{.Name}} ` + completeName + ` -- "${(@)words[2,CURRENT]}" 2>/dev/null)"})
+    compadd -a candidates
+}
+compdef _{{.Func}}_complete {{.Name}}
+`)),
+	"fish": template.Must(template.New("fish").Parse(`# fish completion for {{.Name}}
+function __{{.Func}}_complete
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l current (commandline -ct)
+    {{.Name}} ` + completeName + ` -- $words "$current" 2>/dev/null
+end
+complete -c {{.Name}} -f -a '(__{{.Func}}_complete)'
+`)),
+}
+
+// writeCompletionScript writes the completion script for shell
+func (c *cli) writeCompletionScript(w io.Writer, shell string) error {
+	tmpl, ok := completionScripts[shell]
+	if !ok {
+		return usagef("unsupported shell %q, use bash, zsh or fish", shell)
+	}
+	name := c.name
+	if name == "" {
+		name = filepath.Base(os.Args[0])
+	}
+	// shell function names may not contain every character of a file name
+	fn := strings.Map(func(r rune) rune {
+		if isVarRune(r, false) {
+			return r
+		}
+		return '_'
+	}, name)
+	return tmpl.Execute(w, struct{ Name, Func string }{name, fn})
+}
+
+func (c *cli) completionCommand() *Command {
+	return &Command{
+		Use: "completion",
+		Desc: Description{
+			Short: "Prints a shell completion script",
+			Long:  "'completion bash|zsh|fish' prints a script completing commands and flags of this program, e.g. 'source <(prog completion bash)'.",
+		},
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			if len(args) != 1 {
+				return usagef("usage: completion bash|zsh|fish")
+			}
+			return c.writeCompletionScript(StreamsFrom(ctx).Stdout, args[0])
+		},
+	}
+}
+
+// completeCommand prints completion candidates for the last of the given words, one per line
+func (c *cli) completeCommand() *Command {
+	return &Command{
+		Use: completeName,
+		Desc: Description{
+			Short: "Prints completion candidates for shell completion scripts",
+		},
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			if len(args) == 0 {
+				args = []string{""}
+			}
+			w := StreamsFrom(ctx).Stdout
+			for _, candidate := range c.completeWords(args) {
+				if _, err := fmt.Fprintln(w, candidate); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
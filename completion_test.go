@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func Test_cli_completionCommand(t *testing.T) {
+	c := NewCli(WithName("my-prog"))
+	tests := []struct {
+		shell   string
+		want    []string
+		wantErr error
+	}{
+		{shell: "bash", want: []string{"complete -o default -F _my_prog_complete my-prog", `my-prog __complete -- "${words[@]:1}"`}},
+		{shell: "zsh", want: []string{"#compdef my-prog", "compdef _my_prog_complete my-prog"}},
+		{shell: "fish", want: []string{"complete -c my-prog -f -a '(__my_prog_complete)'"}},
+		{shell: "powershell", wantErr: ErrUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var out strings.Builder
+			ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+			err := c.Execute(ctx, []string{"completion", tt.shell})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("completion %s error = %v, want %v", tt.shell, err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("completion %s output does not contain %q:\n%s", tt.shell, want, out.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_cli_completionCommand_bash(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip(err)
+	}
+	var script strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &script})
+	if err := NewCli(WithName("my-prog")).Execute(ctx, []string{"completion", "bash"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		line       string
+		compWords  string // COMP_WORDS as split by bash at COMP_WORDBREAKS
+		candidates string
+		want       string // arguments of my-prog and COMPREPLY, one per line
+	}{
+		{name: "command", line: "my-prog de", compWords: "my-prog de", candidates: "deploy", want: "__complete\n--\nde\ndeploy\n"},
+		{name: "after space", line: "my-prog deploy ", compWords: "my-prog deploy ''", candidates: "dev\nprod", want: "__complete\n--\ndeploy\n\ndev\nprod\n"},
+		{name: "flag value", line: "my-prog deploy --format=j", compWords: "my-prog deploy --format = j", candidates: "--format=json", want: "__complete\n--\ndeploy\n--format=j\njson\n"},
+		{name: "empty flag value", line: "my-prog deploy --format=", compWords: "my-prog deploy --format =", candidates: "--format=json\n--format=table", want: "__complete\n--\ndeploy\n--format=\n=json\n=table\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(bash, "--norc", "--noprofile", "-c", script.String()+`
+exec 3>&1
+my-prog() { echo "$*" >&3; printf '%b\n' "$CANDIDATES"; }
+COMP_LINE=$LINE COMP_POINT=${#LINE}
+eval "COMP_WORDS=($WORDS)"
+COMP_CWORD=$((${#COMP_WORDS[@]}-1))
+_my_prog_complete
+printf '%s\n' "${COMPREPLY[@]}"
+`)
+			cmd.Env = append(os.Environ(), "LINE="+tt.line, "WORDS="+tt.compWords, "CANDIDATES="+tt.candidates)
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				t.Fatalf("bash error = %v: %s", err, out)
+			}
+			if string(out) != tt.want {
+				t.Errorf("bash completion output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cli_completeCommand(t *testing.T) {
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use: "db",
+		Subcommands: []*Command{
+			{Use: "migrate", Run: func(flags map[string]*ParsedCommandFlags, args []string) {},
+				Flags: []*CommandFlag{{Type: "dry", Long: "dry-run", Kind: KindBool}}},
+			{Use: "status", Run: func(flags map[string]*ParsedCommandFlags, args []string) {}},
+		},
+	})
+	tests := []struct {
+		name string
+		argv []string
+		want string
+	}{
+		{name: "commands", argv: []string{"__complete", "--", "d"}, want: "db\n"},
+		{name: "no words", argv: []string{"__complete"}, want: "completion\ndb\nhelp\nhistory\nset\nsource\n"},
+		{name: "subcommands", argv: []string{"__complete", "--", "db", ""}, want: "migrate\nstatus\n"},
+		{name: "flags", argv: []string{"__complete", "--", "db", "migrate", "--"}, want: "--dry-run\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+			if err := c.Execute(ctx, tt.argv); err != nil {
+				t.Fatalf("cli.Execute() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("cli.Execute(%q) output = %q, want %q", tt.argv, out.String(), tt.want)
+			}
+		})
+	}
+}
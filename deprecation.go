@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// Deprecation marks a command or a flag as deprecated. Deprecated items keep working,
+// but a warning is printed to stderr the first time they are used in a session.
+type Deprecation struct {
+	Message     string `json:"message,omitempty"`     // e.g. 'will be removed in v2'
+	Replacement string `json:"replacement,omitempty"` // command or flag to use instead, e.g. 'db migrate' or '--to'
+}
+
+// warning returns the warning printed for item, e.g. 'command "db up"'
+func (d *Deprecation) warning(item string) string {
+	msg := fmt.Sprintf("warning: %s is deprecated", item)
+	if d.Message != "" {
+		msg += ": " + d.Message
+	}
+	if d.Replacement != "" {
+		msg += fmt.Sprintf(", use %q instead", d.Replacement)
+	}
+	return msg
+}
+
+// note returns the deprecation as it is shown in help, e.g. 'deprecated, use --to'
+func (d *Deprecation) note() string {
+	if d.Replacement != "" {
+		return "deprecated, use " + d.Replacement
+	}
+	return "deprecated"
+}
+
+// warnDeprecated prints warnings for deprecated commands on path and deprecated flags given to the last one,
+// each once per session
+func (c *cli) warnDeprecated(w io.Writer, path []*Command, flags map[string]*ParsedCommandFlags) {
+	var warnings []string
+	for i, cmd := range path {
+		if cmd.Deprecated != nil {
+			warnings = append(warnings, cmd.Deprecated.warning(fmt.Sprintf("command %q", commandPath(path[:i+1]))))
+		}
+	}
+	cmd := path[len(path)-1]
+	for _, f := range cmd.Flags {
+		if _, ok := flags[f.Type]; ok && f.Deprecated != nil {
+			warnings = append(warnings, f.Deprecated.warning(fmt.Sprintf("flag %s of %q", f.display(), commandPath(path))))
+		}
+	}
+	if len(warnings) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.warned == nil {
+		c.warned = make(map[string]bool)
+	}
+	for _, warning := range warnings {
+		if !c.warned[warning] {
+			c.warned[warning] = true
+			io.WriteString(w, warning+"\n")
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_cli_deprecatedAndHidden(t *testing.T) {
+	var ran []string
+	run := func(name string) func(flags map[string]*ParsedCommandFlags, args []string) {
+		return func(flags map[string]*ParsedCommandFlags, args []string) {
+			ran = append(ran, name)
+		}
+	}
+	c := NewCli()
+	c.AddCmd(
+		&Command{
+			Use:  "db",
+			Desc: Description{Short: "Manages the database"},
+			Subcommands: []*Command{
+				{
+					Use: "migrate",
+					Run: run("migrate"),
+					Flags: []*CommandFlag{
+						{Type: "to", Long: "to", Kind: KindInt},
+						{Type: "target", Long: "target", Kind: KindInt, Deprecated: &Deprecation{Replacement: "--to"}},
+						{Type: "trace", Long: "trace", Kind: KindBool, Hidden: true},
+					},
+				},
+				{Use: "up", Desc: Description{Short: "Applies migrations"}, Run: run("up"), Deprecated: &Deprecation{Message: "will be removed in v2", Replacement: "db migrate"}},
+				{Use: "debug", Run: run("debug"), Hidden: true},
+			},
+		},
+		&Command{Use: "internal", Run: run("internal"), Hidden: true},
+	)
+	t.Run("deprecated", func(t *testing.T) {
+		var stderr strings.Builder
+		ctx := ContextWithStreams(context.Background(), Streams{Stderr: &stderr})
+		for _, line := range []string{"db up", "db up", "db migrate --target=3", "db migrate --to=3", "db migrate --target=4"} {
+			if err := c.RunLine(ctx, line); err != nil {
+				t.Fatalf("cli.RunLine(%q) error = %v", line, err)
+			}
+		}
+		if want := []string{"up", "up", "migrate", "migrate", "migrate"}; !reflect.DeepEqual(ran, want) {
+			t.Errorf("ran = %q, want %q", ran, want)
+		}
+		want := `warning: command "db up" is deprecated: will be removed in v2, use "db migrate" instead
+warning: flag --target of "db migrate" is deprecated, use "--to" instead
+`
+		if stderr.String() != want {
+			t.Errorf("stderr =\n%s\nwant\n%s", stderr.String(), want)
+		}
+	})
+	t.Run("hidden", func(t *testing.T) {
+		ran = nil
+		for _, line := range []string{"internal", "db debug", "db migrate --trace"} {
+			if err := c.OneCmd(line); err != nil {
+				t.Fatalf("cli.OneCmd(%q) error = %v", line, err)
+			}
+		}
+		if want := []string{"internal", "debug", "migrate"}; !reflect.DeepEqual(ran, want) {
+			t.Errorf("ran = %q, want %q", ran, want)
+		}
+
+		completions := []struct {
+			line string
+			want []string
+		}{
+			{line: "i", want: nil},
+			{line: "db ", want: []string{"migrate", "up"}},
+			{line: "db migrate --t", want: []string{"--target", "--to"}},
+		}
+		for _, tt := range completions {
+			if got := c.Complete(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cli.Complete(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		}
+
+		help := func(line string) string {
+			var out strings.Builder
+			if err := c.RunLine(ContextWithStreams(context.Background(), Streams{Stdout: &out}), line); err != nil {
+				t.Fatalf("cli.RunLine(%q) error = %v", line, err)
+			}
+			return out.String()
+		}
+		if got := help("help"); strings.Contains(got, "internal") {
+			t.Errorf("help lists a hidden command:\n%s", got)
+		}
+		want := `Usage:
+  db <command>
+
+Manages the database
+
+Commands:
+  migrate
+  up        Applies migrations (deprecated, use db migrate)
+`
+		if got := help("db --help"); got != want {
+			t.Errorf("db --help =\n%s\nwant\n%s", got, want)
+		}
+		want = `Usage:
+  db migrate [flags] [args]
+
+Flags:
+      --to=int
+      --target=int   (deprecated, use --to)
+`
+		if got := help("db migrate --help"); got != want {
+			t.Errorf("db migrate --help =\n%s\nwant\n%s", got, want)
+		}
+		if got := help("help db up"); !strings.Contains(got, "\ncommand \"db up\" is deprecated: will be removed in v2, use \"db migrate\" instead\n") {
+			t.Errorf("help db up does not show the deprecation:\n%s", got)
+		}
+
+		s := c.Schema()
+		if len(s.Commands) != 1 || len(s.Commands[0].Subcommands) != 2 || len(s.Commands[0].Subcommands[0].Flags) != 2 {
+			t.Errorf("cli.Schema() includes hidden items: %+v", s)
+		}
+		if d := s.Commands[0].Subcommands[1].Deprecated; d == nil || d.Replacement != "db migrate" {
+			t.Errorf("cli.Schema() deprecation of db up = %+v", d)
+		}
+	})
+}
+
+func Test_cli_helpOnlyHiddenSubcommands(t *testing.T) {
+	c := NewCli()
+	c.AddCmd(&Command{Use: "ops", Desc: Description{Short: "Internal operations"}, Subcommands: []*Command{
+		{Use: "gc", Hidden: true, Run: func(flags map[string]*ParsedCommandFlags, args []string) {}},
+	}})
+	var out strings.Builder
+	if err := c.RunLine(ContextWithStreams(context.Background(), Streams{Stdout: &out}), "ops --help"); err != nil {
+		t.Fatal(err)
+	}
+	want := `Usage:
+  ops
+
+Internal operations
+`
+	if out.String() != want {
+		t.Errorf("ops --help =\n%s\nwant\n%s", out.String(), want)
+	}
+}
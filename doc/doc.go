@@ -0,0 +1,160 @@
+// Package doc generates reference documentation of the commands registered in a cli.Cli:
+// Markdown files and roff man pages, one per command.
+//
+// The output depends only on the commands and Options, so it can be compared with golden files.
+package doc
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+// Options of generated documentation
+type Options struct {
+	Name    string          // program name prefixing command paths, the base name of os.Args[0] by default
+	Desc    cli.Description // program description shown on the index page
+	Section string          // man page section, "1" by default
+	Date    string          // date in man page headers, omitted if empty
+	Source  string          // source in man page headers, e.g. 'prog 1.2.0'
+	Manual  string          // manual title in man page headers
+}
+
+func (o Options) name() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return filepath.Base(os.Args[0])
+}
+
+func (o Options) section() string {
+	if o.Section != "" {
+		return o.Section
+	}
+	return "1"
+}
+
+// page documents a command, or the program itself on the index page
+type page struct {
+	title    string       // command path, e.g. 'prog db migrate'
+	cmd      *cli.Command // nil on the index page
+	desc     cli.Description
+	parent   *page
+	children []*page
+}
+
+// file returns the file name of the page without extension, e.g. 'prog-db-migrate'
+func (p *page) file() string {
+	return strings.ReplaceAll(p.title, " ", "-")
+}
+
+// pages returns the index page followed by the pages of all commands and subcommands, depth first.
+// Commands are in the order of c.Commands(), subcommands in the order they are declared.
+func pages(c cli.Cli, opts Options) []*page {
+	index := &page{title: opts.name(), desc: opts.Desc}
+	all := []*page{index}
+	var add func(parent *page, cmd *cli.Command)
+	add = func(parent *page, cmd *cli.Command) {
+		p := &page{title: parent.title + " " + cmd.Use, cmd: cmd, desc: cmd.Desc, parent: parent}
+		parent.children = append(parent.children, p)
+		all = append(all, p)
+		for _, sub := range cmd.VisibleSubcommands() {
+			add(p, sub)
+		}
+	}
+	for _, cmd := range c.Commands() {
+		if !cmd.Hidden {
+			add(index, cmd)
+		}
+	}
+	return all
+}
+
+// synopsis returns usage lines of the page
+func (p *page) synopsis() []string {
+	if p.cmd == nil {
+		return []string{p.title + " <command>"}
+	}
+	var lines []string
+	if p.cmd.Runnable() {
+		line := p.title
+		if len(p.cmd.VisibleFlags()) > 0 {
+			line += " [flags]"
+		}
+		lines = append(lines, line+" "+p.cmd.ArgsUsage())
+	}
+	if len(p.cmd.VisibleSubcommands()) > 0 {
+		lines = append(lines, p.title+" <command>")
+	}
+	if len(lines) == 0 {
+		// not runnable and all subcommands are hidden
+		lines = append(lines, p.title)
+	}
+	return lines
+}
+
+// flagDesc returns the description of a flag with its choices, environment variable, requiredness and deprecation
+func flagDesc(f *cli.CommandFlag) string {
+	desc := f.Desc.Short
+	if desc == "" {
+		desc = f.Desc.Long
+	}
+	if len(f.Choices) > 0 {
+		desc = strings.TrimSpace(desc + " (one of " + strings.Join(f.Choices, "|") + ")")
+	}
+	if f.Env != "" {
+		desc = strings.TrimSpace(desc + " (env " + f.Env + ")")
+	}
+	if f.Required {
+		desc = strings.TrimSpace(desc + " (required)")
+	}
+	if d := f.Deprecated; d != nil {
+		note := "deprecated"
+		if d.Replacement != "" {
+			note += ", use " + d.Replacement
+		}
+		desc = strings.TrimSpace(desc + " (" + note + ")")
+	}
+	return desc
+}
+
+// longName returns the long name of a flag as it is shown in docs, e.g. '--to' or '--[no-]color'
+func longName(f *cli.CommandFlag) string {
+	if f.Negatable {
+		return "--[no-]" + f.Long
+	}
+	return "--" + f.Long
+}
+
+// argDesc returns the description of a positional argument with its choices and requiredness
+func argDesc(arg *cli.CommandArg) string {
+	desc := arg.Desc.Short
+	if desc == "" {
+		desc = arg.Desc.Long
+	}
+	if len(arg.Choices) > 0 {
+		desc = strings.TrimSpace(desc + " (one of " + strings.Join(arg.Choices, "|") + ")")
+	}
+	if arg.Required {
+		desc = strings.TrimSpace(desc + " (required)")
+	}
+	return desc
+}
+
+// writeFiles writes every page to dir using the given extension and writer
+func writeFiles(c cli.Cli, dir, ext string, opts Options, write func(b *strings.Builder, p *page, opts Options)) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, p := range pages(c, opts) {
+		var b strings.Builder
+		write(&b, p, opts)
+		content := strings.TrimRight(b.String(), "\n") + "\n"
+		if err := os.WriteFile(filepath.Join(dir, p.file()+ext), []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package doc
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files in testdata")
+
+func testCli() cli.Cli {
+	run := func(flags map[string]*cli.ParsedCommandFlags, args []string) {}
+	c := cli.NewCli()
+	c.AddCmd(
+		&cli.Command{
+			Use: "db",
+			Desc: cli.Description{
+				Short: "Manages the database",
+				Long:  "Runs migrations and reports the schema version.",
+			},
+			Subcommands: []*cli.Command{
+				{
+					Use:     "migrate",
+					Aliases: []string{"m", "mig"},
+					Desc:    cli.Description{Short: "Applies pending migrations"},
+					Example: "prog db migrate --to=42\nprog db m -n",
+					Run:     run,
+					Args: []*cli.CommandArg{
+						{Name: "steps", Desc: cli.Description{Short: "Number of migrations"}, Required: true},
+						{Name: "files", Variadic: true},
+					},
+					Flags: []*cli.CommandFlag{
+						{Type: "dry", Long: "dry-run", Short: "n", Kind: cli.KindBool, Desc: cli.Description{Short: "Prints SQL | does not apply it"}},
+						{Type: "to", Long: "to", Kind: cli.KindInt, Default: "0", Env: "DB_TARGET", Desc: cli.Description{Short: "Target version"}},
+						{Type: "user", Long: "user", Kind: cli.KindString, Required: true, Choices: []string{"admin", "migrator"}},
+						{Type: "trace", Long: "trace", Kind: cli.KindBool, Hidden: true},
+					},
+				},
+				{Use: "debug", Run: run, Hidden: true},
+				{Use: "status", Desc: cli.Description{Short: "Prints the schema version"}, Run: run},
+			},
+		},
+		&cli.Command{
+			Use:  "deploy",
+			Desc: cli.Description{Short: "Deploys the service", Long: ".hidden starts with a dot\n'quoted' too, and a \\ backslash"},
+			Run:  run,
+			Flags: []*cli.CommandFlag{
+				{Type: "color", Long: "color", Kind: cli.KindBool, Negatable: true, Desc: cli.Description{Short: "Colors output"}},
+			},
+		},
+	)
+	return c
+}
+
+var testOptions = Options{
+	Name:   "prog",
+	Desc:   cli.Description{Short: "Operates the service"},
+	Date:   "October 2026",
+	Source: "prog 1.0",
+	Manual: "Prog Manual",
+}
+
+// checkGolden compares files generated in dir with testdata/name, rewriting them with -update
+func checkGolden(t *testing.T, dir, name string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		os.RemoveAll(golden)
+		if err := os.MkdirAll(golden, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, _ := os.ReadDir(golden)
+	if !*update && len(want) != len(entries) {
+		t.Errorf("generated %d files, want %d", len(entries), len(want))
+	}
+	for _, e := range entries {
+		got, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(golden, e.Name())
+		if *update {
+			if err := os.WriteFile(path, got, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			t.Errorf("unexpected file %s: %v", e.Name(), err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("%s differs from %s:\n%s", e.Name(), path, got)
+		}
+	}
+}
+
+func Test_page_synopsis(t *testing.T) {
+	run := func(flags map[string]*cli.ParsedCommandFlags, args []string) {}
+	hidden := &cli.Command{Use: "gc", Run: run, Hidden: true}
+	tests := []struct {
+		name string
+		cmd  *cli.Command
+		want []string
+	}{
+		{name: "index", want: []string{"prog <command>"}},
+		{name: "runnable", cmd: &cli.Command{Use: "ops", Run: run, Subcommands: []*cli.Command{hidden}}, want: []string{"prog ops [args]"}},
+		{name: "only hidden subcommands", cmd: &cli.Command{Use: "ops", Subcommands: []*cli.Command{hidden}}, want: []string{"prog ops"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title := "prog"
+			if tt.cmd != nil {
+				title += " " + tt.cmd.Use
+			}
+			p := &page{title: title, cmd: tt.cmd}
+			if got := p.synopsis(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("page.synopsis() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package doc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+// WriteMan writes a roff man page per command to dir, e.g. 'prog-db-migrate.1',
+// and an index page named after the program listing top-level commands
+func WriteMan(c cli.Cli, dir string, opts Options) error {
+	return writeFiles(c, dir, "."+opts.section(), opts, writeManPage)
+}
+
+func writeManPage(b *strings.Builder, p *page, opts Options) {
+	b.WriteString(".TH")
+	for _, arg := range []string{strings.ToUpper(p.file()), opts.section(), opts.Date, opts.Source, opts.Manual} {
+		b.WriteString(` "` + strings.ReplaceAll(roff(arg), `"`, `\(dq`) + `"`)
+	}
+	b.WriteString("\n")
+	b.WriteString(".SH NAME\n")
+	b.WriteString(roff(p.file()))
+	if p.desc.Short != "" {
+		b.WriteString(` \- ` + roff(p.desc.Short))
+	}
+	b.WriteString("\n.SH SYNOPSIS\n")
+	for i, line := range p.synopsis() {
+		if i > 0 {
+			b.WriteString(".br\n")
+		}
+		fmt.Fprintf(b, "\\fB%s\\fR%s\n", roff(p.title), roff(strings.TrimPrefix(line, p.title)))
+	}
+	if p.desc.Long != "" {
+		fmt.Fprintf(b, ".SH DESCRIPTION\n%s\n", roff(p.desc.Long))
+	}
+	if p.cmd != nil && len(p.cmd.Aliases) > 0 {
+		fmt.Fprintf(b, ".SH ALIASES\n%s\n", roff(strings.Join(p.cmd.Aliases, ", ")))
+	}
+	if p.cmd != nil && len(p.cmd.Args) > 0 {
+		b.WriteString(".SH ARGUMENTS\n")
+		for _, arg := range p.cmd.Args {
+			fmt.Fprintf(b, ".TP\n\\fI%s\\fR\n", roff(arg.Name))
+			writeManText(b, argDesc(arg))
+		}
+	}
+	if p.cmd != nil && len(p.cmd.VisibleFlags()) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		for _, f := range p.cmd.VisibleFlags() {
+			var names []string
+			if f.Short != "" {
+				names = append(names, `\fB`+roff("-"+f.Short)+`\fR`)
+			}
+			if f.Long != "" {
+				names = append(names, `\fB`+roff(longName(f))+`\fR`)
+			}
+			value := ""
+			if f.Kind != "" && f.Kind != cli.KindBool {
+				value = `=\fI` + roff(string(f.Kind)) + `\fR`
+			}
+			fmt.Fprintf(b, ".TP\n%s%s\n", strings.Join(names, ", "), value)
+			desc := flagDesc(f)
+			if f.Default != "" {
+				desc = strings.TrimSpace(desc + " (default " + f.Default + ")")
+			}
+			writeManText(b, desc)
+		}
+	}
+	if p.cmd != nil && p.cmd.Example != "" {
+		fmt.Fprintf(b, ".SH EXAMPLES\n.nf\n%s\n.fi\n", roff(strings.TrimRight(p.cmd.Example, "\n")))
+	}
+	if len(p.children) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, child := range p.children {
+			fmt.Fprintf(b, ".TP\n\\fB%s\\fR(%s)\n%s\n", roff(child.file()), opts.section(), roff(child.desc.Short))
+		}
+	}
+	if p.parent != nil {
+		fmt.Fprintf(b, ".SH SEE ALSO\n\\fB%s\\fR(%s)\n", roff(p.parent.file()), opts.section())
+	}
+}
+
+// writeManText writes an escaped line of text, if any
+func writeManText(b *strings.Builder, s string) {
+	if s != "" {
+		b.WriteString(roff(s) + "\n")
+	}
+}
+
+// roff escapes text so that it is printed as is
+func roff(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		// lines starting with a control character would be taken as requests
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,28 @@
+package doc
+
+import "testing"
+
+func TestWriteMan(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteMan(testCli(), dir, testOptions); err != nil {
+		t.Fatalf("WriteMan() error = %v", err)
+	}
+	checkGolden(t, dir, "man")
+}
+
+func Test_roff(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain text", want: "plain text"},
+		{in: "--flag", want: `\-\-flag`},
+		{in: `a\b`, want: `a\eb`},
+		{in: ".TH\n'x\n y", want: "\\&.TH\n\\&'x\n y"},
+	}
+	for _, tt := range tests {
+		if got := roff(tt.in); got != tt.want {
+			t.Errorf("roff(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
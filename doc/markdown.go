@@ -0,0 +1,84 @@
+package doc
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+// WriteMarkdown writes a Markdown file per command to dir, e.g. 'prog-db-migrate.md',
+// and an index file named after the program listing top-level commands
+func WriteMarkdown(c cli.Cli, dir string, opts Options) error {
+	return writeFiles(c, dir, ".md", opts, writeMarkdownPage)
+}
+
+func writeMarkdownPage(b *strings.Builder, p *page, opts Options) {
+	fmt.Fprintf(b, "# %s\n\n", p.title)
+	if p.desc.Short != "" {
+		fmt.Fprintf(b, "%s\n\n", p.desc.Short)
+	}
+	b.WriteString("## Synopsis\n\n```\n")
+	for _, line := range p.synopsis() {
+		b.WriteString(line + "\n")
+	}
+	b.WriteString("```\n\n")
+	if p.desc.Long != "" {
+		fmt.Fprintf(b, "%s\n\n", p.desc.Long)
+	}
+	if p.cmd != nil && len(p.cmd.Aliases) > 0 {
+		fmt.Fprintf(b, "Aliases: `%s`\n\n", strings.Join(p.cmd.Aliases, "`, `"))
+	}
+	if p.cmd != nil && len(p.cmd.Args) > 0 {
+		b.WriteString("## Arguments\n\n| Argument | Description |\n| --- | --- |\n")
+		for _, arg := range p.cmd.Args {
+			fmt.Fprintf(b, "| `%s` | %s |\n", arg.Name, markdownCell(argDesc(arg)))
+		}
+		b.WriteString("\n")
+	}
+	if p.cmd != nil && len(p.cmd.VisibleFlags()) > 0 {
+		b.WriteString("## Flags\n\n| Flag | Type | Default | Description |\n| --- | --- | --- | --- |\n")
+		for _, f := range p.cmd.VisibleFlags() {
+			var names []string
+			if f.Short != "" {
+				names = append(names, "`-"+f.Short+"`")
+			}
+			if f.Long != "" {
+				names = append(names, "`"+longName(f)+"`")
+			}
+			def := ""
+			if f.Default != "" {
+				def = "`" + f.Default + "`"
+			}
+			fmt.Fprintf(b, "| %s | %s | %s | %s |\n", strings.Join(names, ", "), f.Kind, def, markdownCell(flagDesc(f)))
+		}
+		b.WriteString("\n")
+	}
+	if p.cmd != nil && p.cmd.Example != "" {
+		fmt.Fprintf(b, "## Examples\n\n```\n%s\n```\n\n", strings.TrimRight(p.cmd.Example, "\n"))
+	}
+	if len(p.children) > 0 {
+		b.WriteString("## Commands\n\n")
+		for _, child := range p.children {
+			markdownLink(b, child)
+		}
+		b.WriteString("\n")
+	}
+	if p.parent != nil {
+		b.WriteString("## See also\n\n")
+		markdownLink(b, p.parent)
+	}
+}
+
+func markdownLink(b *strings.Builder, p *page) {
+	fmt.Fprintf(b, "- [%s](%s.md)", p.title, p.file())
+	if p.desc.Short != "" {
+		b.WriteString(" - " + p.desc.Short)
+	}
+	b.WriteString("\n")
+}
+
+// markdownCell escapes text for a table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,11 @@
+package doc
+
+import "testing"
+
+func TestWriteMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteMarkdown(testCli(), dir, testOptions); err != nil {
+		t.Fatalf("WriteMarkdown() error = %v", err)
+	}
+	checkGolden(t, dir, "markdown")
+}
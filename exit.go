@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+// Exit codes returned by ExitCode
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 2   // unknown command, missing subcommand or invalid flag value
+	ExitInterrupted = 130 // the context was canceled, e.g. by Ctrl-C
+)
+
+// ErrUsage matches errors caused by input that does not fit the registered commands
+var ErrUsage = errors.New("usage error")
+
+type usageError struct {
+	msg string
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func (e *usageError) Is(target error) bool {
+	return target == ErrUsage
+}
+
+// ExitError is returned by handlers to exit the process with a specific code from Main
+type ExitError struct {
+	Code int
+	Err  error // optional, printed to stderr by Main
+}
+
+// Exit returns an ExitError with the given code
+func Exit(code int, err error) *ExitError {
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode maps an error returned by the Cli to a process exit code:
+//   - ExitOK for nil
+//   - the code of an ExitError
+//   - ExitInterrupted for context.Canceled
+//   - ExitUsage for ErrUsage and ErrInvalidFlagValue
+//   - ExitFailure otherwise
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	case errors.Is(err, ErrUsage), errors.Is(err, ErrInvalidFlagValue):
+		return ExitUsage
+	}
+	return ExitFailure
+}
+
+// Main runs the command given by os.Args, calls Shutdown and exits the process with ExitCode of the result.
+// An interrupt signal cancels the context of the command. Errors are printed to stderr.
+func (c *cli) Main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx = c.withStreams(ctx)
+	code := c.main(ctx, os.Args[1:], StreamsFrom(ctx).Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func (c *cli) main(ctx context.Context, argv []string, stderr io.Writer) int {
+	err := c.Execute(ctx, argv)
+	if shutdownErr := c.Shutdown(context.Background()); err == nil {
+		err = shutdownErr
+	}
+	var exitErr *ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.Err == nil) {
+		fmt.Fprintln(stderr, err)
+	}
+	return ExitCode(err)
+}
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: ExitOK},
+		{name: "failure", err: errors.New("failed"), want: ExitFailure},
+		{name: "exit error", err: fmt.Errorf("wrapped: %w", Exit(3, nil)), want: 3},
+		{name: "canceled", err: context.Canceled, want: ExitInterrupted},
+		{name: "usage", err: usagef("command %s not found", "x"), want: ExitUsage},
+		{name: "invalid flag value", err: fmt.Errorf("%w: --port", ErrInvalidFlagValue), want: ExitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cli_Execute(t *testing.T) {
+	var gotFlags map[string]*ParsedCommandFlags
+	var gotArgs []string
+	c := NewCli().(*cli)
+	c.AddCmd(&Command{
+		Use: "echo",
+		Flags: []*CommandFlag{{Type: "sep", Long: "sep"}},
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			gotFlags, gotArgs = flags, args
+			if len(args) > 0 && args[0] == "fail" {
+				return Exit(4, nil)
+			}
+			return nil
+		},
+	})
+
+	argv := []string{"echo", `--sep=" "`, `it's`, `"quoted arg"`}
+	if err := c.Execute(context.Background(), argv); err != nil {
+		t.Fatalf("cli.Execute() error = %v", err)
+	}
+	if want := []string{`it's`, `"quoted arg"`}; !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("args = %q, want %q", gotArgs, want)
+	}
+	if got, want := gotFlags["sep"].Args, `" "`; got != want {
+		t.Errorf("flag arg = %q, want %q", got, want)
+	}
+
+	var stderr bytes.Buffer
+	if code := c.main(context.Background(), []string{"echo", "fail"}, &stderr); code != 4 || stderr.Len() != 0 {
+		t.Errorf("cli.main() = %v, stderr %q, want 4 and no output", code, stderr.String())
+	}
+	if code := c.main(context.Background(), []string{"missing"}, &stderr); code != ExitUsage || stderr.Len() == 0 {
+		t.Errorf("cli.main() = %v, stderr %q, want %v and an error", code, stderr.String(), ExitUsage)
+	}
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FlagGroupKind is a rule applied to flags of a FlagGroup
+type FlagGroupKind string
+
+const (
+	FlagsExclusive        FlagGroupKind = "exclusive"         // at most one of the flags may be set, e.g. '--json' or '--table'
+	FlagsRequiredTogether FlagGroupKind = "required-together" // either all or none of the flags are set, e.g. '--user' and '--password'
+	FlagsOneRequired      FlagGroupKind = "one-required"      // at least one of the flags is set
+)
+
+// FlagGroup applies a rule to flags of a command given by Type.
+// A flag is set if it is given or its Env variable is set, Default values do not count.
+type FlagGroup struct {
+	Kind  FlagGroupKind
+	Flags []string // Types of the flags, at least two
+}
+
+// usage returns the flags of the group as they are written in input, e.g. '--json, --table'.
+// Undeclared flags are shown by Type, as groups of commands added with AddCmd are not validated.
+func (g *FlagGroup) usage(cmd *Command) string {
+	names := make([]string, len(g.Flags))
+	for i, id := range g.Flags {
+		names[i] = "--" + id
+		if f := cmd.flagByType(id); f != nil {
+			names[i] = f.display()
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// rule describes the kind of the group in help
+func (g *FlagGroup) rule() string {
+	switch g.Kind {
+	case FlagsExclusive:
+		return "mutually exclusive"
+	case FlagsRequiredTogether:
+		return "required together"
+	case FlagsOneRequired:
+		return "at least one required"
+	}
+	return string(g.Kind)
+}
+
+// check reports a violation of the group rule given flags that are set
+func (g *FlagGroup) check(cmd *Command, set map[string]bool) error {
+	var given, missing []string
+	for _, id := range g.Flags {
+		if set[id] {
+			given = append(given, cmd.flagByType(id).display())
+		} else {
+			missing = append(missing, cmd.flagByType(id).display())
+		}
+	}
+	switch {
+	case g.Kind == FlagsExclusive && len(given) > 1:
+		return usagef("flags %s cannot be used together", strings.Join(given, " and "))
+	case g.Kind == FlagsRequiredTogether && len(given) > 0 && len(missing) > 0:
+		return usagef("flags %s must be used together, missing %s", g.usage(cmd), strings.Join(missing, ", "))
+	case g.Kind == FlagsOneRequired && len(given) == 0:
+		return usagef("one of flags %s is required", g.usage(cmd))
+	}
+	return nil
+}
+
+// checkFlagGroups reports the first violated FlagGroups rule of cmd,
+// or ErrInvalidCommand if the groups are invalid, see validateFlagGroups
+func checkFlagGroups(cmd *Command, path string, flags map[string]*ParsedCommandFlags) error {
+	if len(cmd.FlagGroups) == 0 {
+		return nil
+	}
+	if err := validateFlagGroups(cmd, path); err != nil {
+		return err
+	}
+	set := make(map[string]bool)
+	for _, f := range cmd.Flags {
+		_, given := flags[f.Type]
+		_, env := os.LookupEnv(f.Env)
+		set[f.Type] = given || (f.Env != "" && env)
+	}
+	for _, g := range cmd.FlagGroups {
+		if err := g.check(cmd, set); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFlagGroups checks that groups of cmd have a known kind and refer to declared flags
+func validateFlagGroups(cmd *Command, path string) error {
+	for i, g := range cmd.FlagGroups {
+		switch {
+		case g == nil:
+			return fmt.Errorf("%w: %s: nil flag group", ErrInvalidCommand, path)
+		case !validGroupKind(g.Kind):
+			return fmt.Errorf("%w: %s: flag group %d has unknown kind %q", ErrInvalidCommand, path, i+1, g.Kind)
+		case len(g.Flags) < 2:
+			return fmt.Errorf("%w: %s: flag group %d has less than two flags", ErrInvalidCommand, path, i+1)
+		}
+		seen := make(map[string]bool)
+		for _, id := range g.Flags {
+			if cmd.flagByType(id) == nil {
+				return fmt.Errorf("%w: %s: flag group %d refers to undeclared flag %q", ErrInvalidCommand, path, i+1, id)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: %s: flag group %d lists flag %q twice", ErrInvalidCommand, path, i+1, id)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+func validGroupKind(kind FlagGroupKind) bool {
+	switch kind {
+	case FlagsExclusive, FlagsRequiredTogether, FlagsOneRequired:
+		return true
+	}
+	return false
+}
+
+// flagByType returns a flag by Type, or nil
+func (c *Command) flagByType(id string) *CommandFlag {
+	for _, f := range c.Flags {
+		if f.Type == id {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func Test_cli_flagGroups(t *testing.T) {
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use: "export",
+		Flags: []*CommandFlag{
+			{Type: "json", Long: "json", Kind: KindBool},
+			{Type: "table", Long: "table", Short: "t", Kind: KindBool},
+			{Type: "user", Long: "user", Kind: KindString, Default: "root"},
+			{Type: "password", Long: "password", Kind: KindString, Env: "CLI_TEST_PASSWORD"},
+		},
+		FlagGroups: []*FlagGroup{
+			{Kind: FlagsExclusive, Flags: []string{"json", "table"}},
+			{Kind: FlagsOneRequired, Flags: []string{"json", "table"}},
+			{Kind: FlagsRequiredTogether, Flags: []string{"user", "password"}},
+		},
+		Run: func(flags map[string]*ParsedCommandFlags, args []string) {},
+	})
+	tests := []struct {
+		name    string
+		env     string
+		line    string
+		wantErr string
+	}{
+		{name: "one of exclusive", line: "export --json"},
+		{name: "exclusive", line: "export --json -t", wantErr: "flags --json and --table cannot be used together"},
+		{name: "none of one required", line: "export", wantErr: "one of flags --json, --table is required"},
+		{name: "together", line: "export -t --user=bob --password=secret"},
+		{name: "together from env", env: "secret", line: "export -t --user=bob"},
+		{name: "default does not count", line: "export -t --password=secret", wantErr: "flags --user, --password must be used together, missing --user"},
+		{name: "missing together", line: "export -t --user=bob", wantErr: "flags --user, --password must be used together, missing --password"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("CLI_TEST_PASSWORD", tt.env)
+			}
+			err := c.RunLine(context.Background(), tt.line)
+			if errText(err) != tt.wantErr {
+				t.Fatalf("cli.RunLine() error = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUsage) {
+				t.Errorf("cli.RunLine() error = %v, want a usage error", err)
+			}
+		})
+	}
+
+	var out strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+	if err := c.RunLine(ctx, "help export"); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+Flag groups:
+  --json, --table      mutually exclusive
+  --json, --table      at least one required
+  --user, --password   required together
+`
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("help output =\n%s\nwant suffix\n%s", out.String(), want)
+	}
+
+	groups := c.Schema().Commands[0].FlagGroups
+	if len(groups) != 3 || groups[2].Kind != FlagsRequiredTogether || strings.Join(groups[2].Flags, ",") != "user,password" {
+		t.Errorf("cli.Schema() flag groups = %+v", groups)
+	}
+}
+
+func Test_cli_invalidFlagGroup(t *testing.T) {
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use:        "g",
+		Flags:      []*CommandFlag{{Type: "a", Long: "a", Kind: KindBool}},
+		FlagGroups: []*FlagGroup{{Kind: FlagsExclusive, Flags: []string{"a", "b"}}},
+		Run:        func(flags map[string]*ParsedCommandFlags, args []string) {},
+	})
+	if err := c.RunLine(context.Background(), "g --a"); !errors.Is(err, ErrInvalidCommand) {
+		t.Errorf("cli.RunLine() error = %v, want %v", err, ErrInvalidCommand)
+	}
+	var out strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+	if err := c.RunLine(ctx, "g --help"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "--a, --b   mutually exclusive"; !strings.Contains(out.String(), want) {
+		t.Errorf("help output =\n%s\nwant %q", out.String(), want)
+	}
+}
+
+func Test_validateFlagGroups(t *testing.T) {
+	flags := []*CommandFlag{{Type: "a", Long: "a"}, {Type: "b", Long: "b"}}
+	tests := []struct {
+		name    string
+		groups  []*FlagGroup
+		wantErr bool
+	}{
+		{name: "valid", groups: []*FlagGroup{{Kind: FlagsExclusive, Flags: []string{"a", "b"}}}},
+		{name: "nil", groups: []*FlagGroup{nil}, wantErr: true},
+		{name: "unknown kind", groups: []*FlagGroup{{Kind: "some", Flags: []string{"a", "b"}}}, wantErr: true},
+		{name: "one flag", groups: []*FlagGroup{{Kind: FlagsOneRequired, Flags: []string{"a"}}}, wantErr: true},
+		{name: "undeclared flag", groups: []*FlagGroup{{Kind: FlagsExclusive, Flags: []string{"a", "c"}}}, wantErr: true},
+		{name: "flag twice", groups: []*FlagGroup{{Kind: FlagsExclusive, Flags: []string{"a", "a"}}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewCli().Register(&Command{Use: "cmd", Flags: flags, FlagGroups: tt.groups})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cli.Register() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCommand) {
+				t.Errorf("cli.Register() error = %v, want ErrInvalidCommand", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+)
+
+// otherGroupTitle is the title of the help section listing commands outside of groups set by WithGroups
+const otherGroupTitle = "Other"
+
+// CommandGroup is a section of commands in help, selected by Command.Group
+type CommandGroup struct {
+	ID    string `json:"id"`              // value of Command.Group
+	Title string `json:"title,omitempty"` // section title, ID if empty
+}
+
+// Sets groups of commands and their order in help. Commands whose Group is not set or unknown
+// are listed in an "Other" section after the groups. Without groups commands are listed in one section.
+func WithGroups(groups ...CommandGroup) Option {
+	return func(c *cli) {
+		c.groups = append(c.groups, groups...)
+	}
+}
+
+// writeCommandList writes cmds, except hidden ones, under title, or in sections of groups if any is set
+func (c *cli) writeCommandList(w io.Writer, title string, cmds []*Command) {
+	var visible []*Command
+	for _, cmd := range cmds {
+		if !cmd.Hidden {
+			visible = append(visible, cmd)
+		}
+	}
+	if len(c.groups) == 0 {
+		writeSection(w, title, visible)
+		return
+	}
+	known := make(map[string]bool)
+	first := true
+	write := func(title string, cmds []*Command) {
+		if len(cmds) == 0 {
+			return
+		}
+		if !first {
+			fmt.Fprintln(w)
+		}
+		first = false
+		writeSection(w, title, cmds)
+	}
+	for _, g := range c.groups {
+		known[g.ID] = true
+		var members []*Command
+		for _, cmd := range visible {
+			if cmd.Group == g.ID {
+				members = append(members, cmd)
+			}
+		}
+		title := g.Title
+		if title == "" {
+			title = g.ID
+		}
+		write(title, members)
+	}
+	var other []*Command
+	for _, cmd := range visible {
+		if !known[cmd.Group] {
+			other = append(other, cmd)
+		}
+	}
+	write(otherGroupTitle, other)
+}
+
+func writeSection(w io.Writer, title string, cmds []*Command) {
+	fmt.Fprintf(w, "%s:\n", title)
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.Use, commandHelp(cmd))
+	}
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func Test_cli_helpGroups(t *testing.T) {
+	run := func(flags map[string]*ParsedCommandFlags, args []string) {}
+	commands := func() []*Command {
+		return []*Command{
+			{Use: "migrate", Group: "db", Desc: Description{Short: "Applies migrations"}, Run: run},
+			{Use: "backup", Group: "db", Run: run},
+			{Use: "deploy", Group: "ops", Desc: Description{Short: "Deploys"}, Run: run},
+			{Use: "version", Run: run},
+			{Use: "legacy", Group: "unknown", Run: run},
+			{Use: "debug", Group: "db", Hidden: true, Run: run},
+			{Use: "tools", Subcommands: []*Command{
+				{Use: "lint", Group: "check", Run: run},
+				{Use: "fmt", Run: run},
+			}},
+		}
+	}
+	tests := []struct {
+		name   string
+		groups []CommandGroup
+		line   string
+		want   string
+	}{
+		{
+			name:   "grouped",
+			groups: []CommandGroup{{ID: "ops", Title: "Operations"}, {ID: "db", Title: "Database"}, {ID: "empty"}},
+			line:   "help",
+			want: `Operations:
+  deploy   Deploys
+
+Database:
+  backup
+  migrate   Applies migrations
+
+Other:
+  legacy
+  tools
+  version
+`,
+		},
+		{
+			name: "without groups",
+			line: "help",
+			want: `Commands:
+  backup
+  deploy    Deploys
+  legacy
+  migrate   Applies migrations
+  tools
+  version
+`,
+		},
+		{
+			name:   "subcommands",
+			groups: []CommandGroup{{ID: "check"}},
+			line:   "tools --help",
+			want: `Usage:
+  tools <command>
+
+check:
+  lint
+
+Other:
+  fmt
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCli(WithGroups(tt.groups...))
+			c.AddCmd(commands()...)
+			var out strings.Builder
+			if err := c.RunLine(ContextWithStreams(context.Background(), Streams{Stdout: &out}), tt.line); err != nil {
+				t.Fatal(err)
+			}
+			got, _, _ := strings.Cut(out.String(), "\nBuilt-in commands:")
+			if got != tt.want {
+				t.Errorf("cli.RunLine(%q) output =\n%s\nwant\n%s", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cli_SchemaGroups(t *testing.T) {
+	c := NewCli(WithGroups(CommandGroup{ID: "db", Title: "Database"}))
+	c.AddCmd(&Command{Use: "migrate", Group: "db", Run: func(flags map[string]*ParsedCommandFlags, args []string) {}})
+	s := c.Schema()
+	if len(s.Groups) != 1 || s.Groups[0].Title != "Database" || s.Commands[0].Group != "db" {
+		t.Errorf("cli.Schema() = %+v, want the group and the group of migrate", s)
+	}
+}
@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Flags showing help instead of running a command, unless the command declares flags with the same names
+const (
+	helpFlag      = "help" // '--help' or '-h', help text
+	helpShortFlag = "h"
+	helpJSONFlag  = "help-json" // '--help-json', the command schema as JSON
+)
+
+// helpMode returns the help flag given in parsed input, or an empty string
+func helpMode(cmd *Command, parsed *ParsedCommand) string {
+	for _, name := range []string{helpJSONFlag, helpFlag, helpShortFlag} {
+		if _, ok := parsed.Flags[name]; ok && cmd.GetFlag(name) == nil {
+			if name == helpShortFlag {
+				return helpFlag
+			}
+			return name
+		}
+	}
+	return ""
+}
+
+// showHelp writes help of the command at path, or of all commands if path is empty, in the given mode
+func (c *cli) showHelp(w io.Writer, path []*Command, mode string) error {
+	if mode == helpJSONFlag {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if len(path) == 0 {
+			return enc.Encode(c.Schema())
+		}
+		return enc.Encode(commandSchema(path[len(path)-1], commandPath(path)))
+	}
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	if len(path) == 0 {
+		c.writeCommandsHelp(tw)
+	} else {
+		c.writeCommandHelp(tw, path)
+	}
+	tw.Flush()
+	// cells without a description leave padding at the end of lines
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n"))
+	return err
+}
+
+// writeCommandsHelp lists registered commands, in sections of groups if any, and built-in commands
+func (c *cli) writeCommandsHelp(w io.Writer) {
+	c.writeCommandList(w, "Commands", c.Commands())
+	fmt.Fprintln(w, "\nBuilt-in commands:")
+	for _, name := range matchPrefix(c.commandNames(), "") {
+		if cmd, ok := c.builtins[name]; ok {
+			fmt.Fprintf(w, "  %s\t%s\n", cmd.Use, cmd.Desc.Short)
+		}
+	}
+	fmt.Fprintln(w, "\nRun 'help <command>' or '<command> --help' for details.")
+}
+
+// writeCommandHelp writes usage, descriptions, arguments, flags, flag groups, subcommands and examples of the last command on path
+func (c *cli) writeCommandHelp(w io.Writer, path []*Command) {
+	cmd := path[len(path)-1]
+	name := commandPath(path)
+	fmt.Fprintln(w, "Usage:")
+	flags, subs := cmd.VisibleFlags(), cmd.VisibleSubcommands()
+	if cmd.Runnable() {
+		line := name
+		if len(flags) > 0 {
+			line += " [flags]"
+		}
+		fmt.Fprintf(w, "  %s %s\n", line, cmd.ArgsUsage())
+	}
+	if len(subs) > 0 {
+		fmt.Fprintf(w, "  %s <command>\n", name)
+	}
+	if !cmd.Runnable() && len(subs) == 0 {
+		// all subcommands are hidden
+		fmt.Fprintf(w, "  %s\n", name)
+	}
+	if d := cmd.Deprecated; d != nil {
+		fmt.Fprintf(w, "\n%s\n", strings.TrimPrefix(d.warning(fmt.Sprintf("command %q", name)), "warning: "))
+	}
+	for _, desc := range []string{cmd.Desc.Short, cmd.Desc.Long} {
+		if desc != "" {
+			fmt.Fprintf(w, "\n%s\n", desc)
+		}
+	}
+	if len(cmd.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(cmd.Aliases, ", "))
+	}
+	if len(cmd.Args) > 0 {
+		fmt.Fprintln(w, "\nArguments:")
+		for _, arg := range cmd.Args {
+			desc := arg.Desc.Short
+			var notes []string
+			if choices := choicesHelp(arg.Choices); choices != "" {
+				notes = append(notes, choices)
+			}
+			if arg.Required {
+				notes = append(notes, "required")
+			}
+			if len(notes) > 0 {
+				desc = strings.TrimSpace(desc + " (" + strings.Join(notes, ", ") + ")")
+			}
+			fmt.Fprintf(w, "  %s\t%s\n", arg.Name, desc)
+		}
+	}
+	if len(flags) > 0 {
+		fmt.Fprintln(w, "\nFlags:")
+		for _, f := range flags {
+			fmt.Fprintf(w, "  %s\t%s\n", flagUsage(f), flagHelp(f))
+		}
+	}
+	if len(cmd.FlagGroups) > 0 {
+		fmt.Fprintln(w, "\nFlag groups:")
+		for _, g := range cmd.FlagGroups {
+			if g == nil {
+				continue
+			}
+			fmt.Fprintf(w, "  %s\t%s\n", g.usage(cmd), g.rule())
+		}
+	}
+	if len(subs) > 0 {
+		fmt.Fprintln(w)
+		c.writeCommandList(w, "Commands", subs)
+	}
+	if cmd.Example != "" {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, line := range strings.Split(strings.TrimRight(cmd.Example, "\n"), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+// flagUsage returns the flag names with its value kind, e.g. '-t, --to=int' or '--[no-]color'
+func flagUsage(f *CommandFlag) string {
+	usage := "    "
+	if f.Short != "" {
+		usage = "-" + f.Short
+		if f.Long != "" {
+			usage += ", "
+		}
+	}
+	if f.Long != "" && f.Negatable {
+		usage += "--[" + negationPrefix + "]" + f.Long
+	} else if f.Long != "" {
+		usage += "--" + f.Long
+	}
+	if f.Kind != "" && f.Kind != KindBool {
+		usage += "=" + string(f.Kind)
+	}
+	return usage
+}
+
+// flagHelp returns the flag description with its choices, default, environment variable, requiredness and deprecation
+func flagHelp(f *CommandFlag) string {
+	var notes []string
+	if choices := choicesHelp(f.Choices); choices != "" {
+		notes = append(notes, choices)
+	}
+	if f.Default != "" {
+		notes = append(notes, "default "+f.Default)
+	}
+	if f.Env != "" {
+		notes = append(notes, "env "+f.Env)
+	}
+	if f.Required {
+		notes = append(notes, "required")
+	}
+	if f.Deprecated != nil {
+		notes = append(notes, f.Deprecated.note())
+	}
+	if len(notes) == 0 {
+		return f.Desc.Short
+	}
+	return strings.TrimSpace(f.Desc.Short + " (" + strings.Join(notes, ", ") + ")")
+}
+
+// commandHelp returns the short description of a command listed in help, noting its deprecation
+func commandHelp(cmd *Command) string {
+	if cmd.Deprecated == nil {
+		return cmd.Desc.Short
+	}
+	return strings.TrimSpace(cmd.Desc.Short + " (" + cmd.Deprecated.note() + ")")
+}
+
+// checkRequired reports a required flag given neither in flags nor by Env or Default
+func checkRequired(cmd *Command, flags map[string]*ParsedCommandFlags) error {
+	for _, f := range cmd.Flags {
+		if !f.Required || f.Default != "" {
+			continue
+		}
+		if _, ok := flags[f.Type]; ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(f.Env); f.Env != "" && ok {
+			continue
+		}
+		return usagef("required flag %s not set", f.display())
+	}
+	return nil
+}
+
+func (c *cli) helpCommand() *Command {
+	return &Command{
+		Use: "help",
+		Desc: Description{
+			Short: "Prints help of commands",
+			Long:  "'help' lists all commands, 'help <command> [subcommands]' describes a command.",
+		},
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			var path []*Command
+			if len(args) > 0 {
+				if path = c.resolvePath(args); path == nil {
+					return usagef("command %s not found", args[0])
+				}
+				if len(path) < len(args) {
+					return usagef("command %s not found", strings.Join(args, " "))
+				}
+			}
+			return c.showHelp(StreamsFrom(ctx).Stdout, path, helpFlag)
+		},
+	}
+}
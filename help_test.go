@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func helpTestCli() Cli {
+	run := func(flags map[string]*ParsedCommandFlags, args []string) {}
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use:  "db",
+		Desc: Description{Short: "Manages the database"},
+		Subcommands: []*Command{
+			{
+				Use:     "migrate",
+				Aliases: []string{"m"},
+				Desc:    Description{Short: "Applies migrations", Long: "Applies pending migrations in order."},
+				Example: "db migrate 3\ndb m --to=42 1",
+				Run:     run,
+				Args: []*CommandArg{
+					{Name: "steps", Desc: Description{Short: "Number of migrations"}, Required: true},
+					{Name: "files", Variadic: true},
+				},
+				Flags: []*CommandFlag{
+					{Type: "dry", Long: "dry-run", Short: "n", Kind: KindBool, Desc: Description{Short: "Prints SQL"}},
+					{Type: "to", Long: "to", Kind: KindInt, Default: "0", Desc: Description{Short: "Target version"}},
+					{Type: "user", Long: "user", Kind: KindString, Env: "CLI_TEST_DB_USER", Required: true},
+				},
+			},
+		},
+	})
+	return c
+}
+
+func Test_cli_help(t *testing.T) {
+	const migrateHelp = `Usage:
+  db migrate [flags] <steps> [files...]
+
+Applies migrations
+
+Applies pending migrations in order.
+
+Aliases: m
+
+Arguments:
+  steps   Number of migrations (required)
+  files
+
+Flags:
+  -n, --dry-run       Prints SQL
+      --to=int        Target version (default 0)
+      --user=string   (env CLI_TEST_DB_USER, required)
+
+Examples:
+  db migrate 3
+  db m --to=42 1
+`
+	tests := []struct {
+		name string
+		argv []string
+		want string
+	}{
+		{name: "help command", argv: []string{"help", "db", "migrate"}, want: migrateHelp},
+		{name: "help flag", argv: []string{"db", "m", "--help"}, want: migrateHelp},
+		{name: "short help flag", argv: []string{"db", "migrate", "-h"}, want: migrateHelp},
+		{name: "group", argv: []string{"db", "--help"}, want: "Usage:\n  db <command>\n\nManages the database\n\nCommands:\n  migrate   Applies migrations\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+			if err := helpTestCli().Execute(ctx, tt.argv); err != nil {
+				t.Fatalf("cli.Execute() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("cli.Execute(%q) output =\n%s\nwant\n%s", tt.argv, out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func Test_cli_helpList(t *testing.T) {
+	for _, argv := range [][]string{{"help"}, {"--help"}} {
+		var out strings.Builder
+		ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+		if err := helpTestCli().Execute(ctx, argv); err != nil {
+			t.Fatalf("cli.Execute(%q) error = %v", argv, err)
+		}
+		for _, want := range []string{"Commands:\n  db  ", "Manages the database", "\n  help ", "\n  set "} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("cli.Execute(%q) output does not contain %q:\n%s", argv, want, out.String())
+			}
+		}
+		if strings.Contains(out.String(), "__complete") {
+			t.Errorf("cli.Execute(%q) output lists internal commands:\n%s", argv, out.String())
+		}
+	}
+}
+
+func Test_cli_checkInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		line    string
+		wantErr string
+	}{
+		{name: "valid", line: "db migrate --user=bob 1 a b"},
+		{name: "required flag from env", env: "alice", line: "db migrate 1"},
+		{name: "missing required flag", line: "db migrate 1", wantErr: "required flag --user not set"},
+		{name: "missing argument", line: "db migrate --user=bob", wantErr: "missing argument <steps>"},
+		{name: "unknown help command", line: "help db nope", wantErr: "command db nope not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("CLI_TEST_DB_USER", tt.env)
+			}
+			err := helpTestCli().RunLine(context.Background(), tt.line)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Fatalf("cli.RunLine() error = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUsage) {
+				t.Errorf("cli.RunLine() error = %v, want a usage error", err)
+			}
+		})
+	}
+}
+
+func TestCommand_checkArgs(t *testing.T) {
+	cmd := &Command{Args: []*CommandArg{{Name: "src", Required: true}, {Name: "dst"}}}
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: nil, wantErr: true},
+		{args: []string{"a"}},
+		{args: []string{"a", "b"}},
+		{args: []string{"a", "b", "c"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := cmd.checkArgs(tt.args); (err != nil) != tt.wantErr {
+			t.Errorf("Command.checkArgs(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+		}
+	}
+	if err := (&Command{}).checkArgs([]string{"any"}); err != nil {
+		t.Errorf("Command.checkArgs() without specs error = %v", err)
+	}
+}
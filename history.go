@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultHistorySize is the default number of entries kept by History
+const DefaultHistorySize = 1000
+
+// ErrEventNotFound is returned when a history expansion like !n does not match any entry
+var ErrEventNotFound = errors.New("event not found")
+
+// History is an append-only store of command lines, optionally backed by a file.
+//
+// Every entry is appended to the file as it is added; once the file holds twice as many entries
+// as the size limit, it is rewritten to keep only the latest entries.
+type History struct {
+	mu        sync.Mutex
+	path      string
+	size      int
+	dedup     bool
+	redact    func(line string) (string, bool)
+	entries   []string
+	fileLines int
+}
+
+// HistoryOption configures a History
+type HistoryOption func(h *History)
+
+// Sets the number of entries kept, DefaultHistorySize by default
+func WithHistorySize(size int) HistoryOption {
+	return func(h *History) {
+		h.size = size
+	}
+}
+
+// Sets whether a line equal to the previous entry is skipped, true by default
+func WithHistoryDedup(dedup bool) HistoryOption {
+	return func(h *History) {
+		h.dedup = dedup
+	}
+}
+
+// Sets a function applied to every line before it is stored.
+// It returns the line to store, e.g. with secrets masked, or false to skip the line.
+func WithHistoryRedactor(redact func(line string) (string, bool)) HistoryOption {
+	return func(h *History) {
+		h.redact = redact
+	}
+}
+
+// OpenHistory creates a History backed by the file at path, loading entries it already has.
+// An empty path keeps the history in memory only.
+func OpenHistory(path string, opts ...HistoryOption) (*History, error) {
+	h := &History{
+		path:  path,
+		size:  DefaultHistorySize,
+		dedup: true,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.size < 1 {
+		h.size = 1
+	}
+	if path == "" {
+		return h, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		h.entries = append(h.entries, decodeHistoryLine(s.Text()))
+		h.fileLines++
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(h.entries) > h.size {
+		h.entries = h.entries[len(h.entries)-h.size:]
+	}
+	return h, nil
+}
+
+// Add stores a line after redaction. Blank lines and, with dedup, repeats of the last entry are skipped.
+func (h *History) Add(line string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.redact != nil {
+		var ok bool
+		if line, ok = h.redact(line); !ok {
+			return nil
+		}
+	}
+	if strings.TrimSpace(line) == "" {
+		return nil
+	}
+	if h.dedup && len(h.entries) > 0 && h.entries[len(h.entries)-1] == line {
+		return nil
+	}
+	h.entries = append(h.entries, line)
+	if len(h.entries) > h.size {
+		h.entries = append(h.entries[:0], h.entries[len(h.entries)-h.size:]...)
+	}
+	if h.path == "" {
+		return nil
+	}
+	if h.fileLines+1 >= 2*h.size {
+		return h.rewrite()
+	}
+	f, err := os.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(f, encodeHistoryLine(line)+"\n"); err != nil {
+		f.Close()
+		return err
+	}
+	h.fileLines++
+	return f.Close()
+}
+
+// rewrite replaces the file with the kept entries
+func (h *History) rewrite() error {
+	tmp, err := os.CreateTemp(filepath.Dir(h.path), filepath.Base(h.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for _, entry := range h.entries {
+		w.WriteString(encodeHistoryLine(entry) + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), h.path); err != nil {
+		return err
+	}
+	h.fileLines = len(h.entries)
+	return nil
+}
+
+// Entries returns a copy of the stored lines, oldest first
+func (h *History) Entries() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.entries...)
+}
+
+// Expand replaces history references in line and reports whether there were any:
+//
+//	!!		- the last entry
+//	!n		- entry n as numbered by the history command
+//	!-n		- the n-th entry from the end
+//	!prefix		- the latest entry starting with prefix
+//
+// References are not expanded in single quotes; a single quote inside double quotes is literal.
+// A '!' followed by a space, '=', '(', a character ending the reference such as ';' or '"',
+// or the end of line is kept.
+func (h *History) Expand(line string) (string, bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var b strings.Builder
+	expanded := false
+	var quote byte // the open quote, or 0
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case quote == 0 && (ch == '\'' || ch == '"'):
+			quote = ch
+		case ch == quote:
+			quote = 0
+		}
+		if ch != '!' || quote == '\'' || i+1 >= len(line) || strings.IndexByte(" \t=(", line[i+1]) >= 0 {
+			b.WriteByte(ch)
+			continue
+		}
+		end := i + 2
+		if line[i+1] != '!' {
+			end = i + 1
+			for end < len(line) && strings.IndexByte(" \t;|&<>()'\"", line[end]) < 0 {
+				end++
+			}
+			if end == i+1 {
+				b.WriteByte(ch)
+				continue
+			}
+		}
+		event := line[i:end]
+		entry, ok := h.event(event[1:])
+		if !ok {
+			return "", false, fmt.Errorf("%w: %s", ErrEventNotFound, event)
+		}
+		b.WriteString(entry)
+		expanded = true
+		i = end - 1
+	}
+	return b.String(), expanded, nil
+}
+
+// event resolves a history reference without the leading '!'
+func (h *History) event(ref string) (string, bool) {
+	if ref == "!" {
+		ref = "-1"
+	}
+	if n, err := strconv.Atoi(ref); err == nil {
+		if n < 0 {
+			n = len(h.entries) + n + 1
+		}
+		if n < 1 || n > len(h.entries) {
+			return "", false
+		}
+		return h.entries[n-1], true
+	}
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		if strings.HasPrefix(h.entries[i], ref) {
+			return h.entries[i], true
+		}
+	}
+	return "", false
+}
+
+// entries are stored one per line with backslashes and line breaks escaped
+func encodeHistoryLine(line string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(line)
+}
+
+func decodeHistoryLine(line string) string {
+	var b strings.Builder
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' && i+1 < len(line) {
+			i++
+			if line[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(line[i])
+	}
+	return b.String()
+}
+
+// Sets the history used by RunLine to expand references like !! and to record lines
+func WithHistory(h *History) Option {
+	return func(c *cli) {
+		c.history = h
+	}
+}
+
+func (c *cli) historyCommand() *Command {
+	return &Command{
+		Use: "history",
+		Desc: Description{
+			Short: "Prints the command history",
+			Long:  "'history' prints all numbered entries, 'history <n>' prints the last n entries.",
+		},
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			if c.history == nil {
+				return errors.New("history is disabled")
+			}
+			entries := c.history.Entries()
+			first := 0
+			if len(args) > 0 {
+				n, err := strconv.Atoi(args[0])
+				if err != nil || n < 0 || len(args) > 1 {
+					return usagef("usage: history [<n>]")
+				}
+				first = max(0, len(entries)-n)
+			}
+			w := bufio.NewWriter(StreamsFrom(ctx).Stdout)
+			for i := first; i < len(entries); i++ {
+				fmt.Fprintf(w, "%5d  %s\n", i+1, entries[i])
+			}
+			return w.Flush()
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHistory_Expand(t *testing.T) {
+	h, _ := OpenHistory("")
+	for _, line := range []string{"first a", "second b", "first c"} {
+		h.Add(line)
+	}
+	tests := []struct {
+		name     string
+		line     string
+		want     string
+		expanded bool
+		wantErr  error
+	}{
+		{name: "last", line: "!! | tail", want: "first c | tail", expanded: true},
+		{name: "by number", line: "!2", want: "second b", expanded: true},
+		{name: "from the end", line: "!-3;!-1", want: "first a;first c", expanded: true},
+		{name: "by prefix", line: "x && !sec", want: "x && second b", expanded: true},
+		{name: "single quotes", line: "echo '!!' !!", want: "echo '!!' first c", expanded: true},
+		{name: "literal bang", line: "echo ! != !", want: "echo ! != !"},
+		{name: "single quote in double quotes", line: `echo "it's" !!`, want: `echo "it's" first c`, expanded: true},
+		{name: "double quote in single quotes", line: `echo '"' !! '!!'`, want: `echo '"' first c '!!'`, expanded: true},
+		{name: "bang before quote", line: `echo "wow!"`, want: `echo "wow!"`},
+		{name: "bang before semicolon", line: "hi!;x", want: "hi!;x"},
+		{name: "bang before paren", line: "(a!)", want: "(a!)"},
+		{name: "unknown number", line: "!9", wantErr: ErrEventNotFound},
+		{name: "unknown prefix", line: "!third", wantErr: ErrEventNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, expanded, err := h.Expand(tt.line)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("History.Expand() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (got != tt.want || expanded != tt.expanded) {
+				t.Errorf("History.Expand() = %q, %v, want %q, %v", got, expanded, tt.want, tt.expanded)
+			}
+		})
+	}
+}
+
+func TestHistory_file(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	redact := func(line string) (string, bool) {
+		if strings.HasPrefix(line, " ") {
+			return "", false
+		}
+		return strings.ReplaceAll(line, "secret", "***"), true
+	}
+	h, err := OpenHistory(path, WithHistorySize(3), WithHistoryRedactor(redact))
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	for _, line := range []string{"a", "a", " hidden", "login secret", "multi\nline \\n", "b", "c", "d"} {
+		if err := h.Add(line); err != nil {
+			t.Fatalf("History.Add() error = %v", err)
+		}
+	}
+	want := []string{"b", "c", "d"}
+	if got := h.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("History.Entries() = %q, want %q", got, want)
+	}
+
+	data, _ := os.ReadFile(path)
+	if lines := strings.Count(string(data), "\n"); lines >= 6 {
+		t.Errorf("history file has %d lines, must be compacted", lines)
+	}
+
+	h.Add("e\nf")
+	reopened, err := OpenHistory(path, WithHistorySize(3))
+	if err != nil {
+		t.Fatalf("OpenHistory() error = %v", err)
+	}
+	want = []string{"c", "d", "e\nf"}
+	if got := reopened.Entries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened History.Entries() = %q, want %q", got, want)
+	}
+}
+
+func Test_cli_history(t *testing.T) {
+	var calls []string
+	h, _ := OpenHistory("")
+	c := NewCli(WithHistory(h))
+	c.AddCmd(&Command{
+		Use: "do",
+		Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+			calls = append(calls, strings.Join(args, " "))
+		},
+	})
+	var out, errOut strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out, Stderr: &errOut})
+	for _, line := range []string{"do 1", "do 2", "!! && !-2", "history 2"} {
+		if err := c.RunLine(ctx, line); err != nil {
+			t.Fatalf("cli.RunLine(%q) error = %v", line, err)
+		}
+	}
+	if want := []string{"1", "2", "2", "1"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+	if want := "do 2 && do 1\n"; errOut.String() != want {
+		t.Errorf("echoed line = %q, want %q", errOut.String(), want)
+	}
+	if want := "    3  do 2 && do 1\n    4  history 2\n"; out.String() != want {
+		t.Errorf("history output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/AlexandrShapkin/cli/lineedit"
+)
+
+// Interact runs command lines read from the stdin of ctx (see StreamsFrom) until end of input.
+// If stdin is a terminal, lines are read with a line editor providing Emacs key bindings,
+// history navigation (see WithHistory), Ctrl-R reverse search and Tab completion (see Complete).
+// Errors of command lines are printed to stderr, Ctrl-C discards the line being edited.
+func (c *cli) Interact(ctx context.Context, prompt string) error {
+	return c.InteractWith(ctx, prompt, c.RunLine)
+}
+
+// InteractWith is Interact passing every non-blank line to run instead of RunLine,
+// e.g. to record or wrap command lines. The context given to run carries the streams of the Cli.
+func (c *cli) InteractWith(ctx context.Context, prompt string, run func(ctx context.Context, line string) error) error {
+	ctx = c.withStreams(ctx)
+	streams := StreamsFrom(ctx)
+	readLine := c.lineReader(streams)
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		line, err := readLine(prompt)
+		if errors.Is(err, lineedit.ErrInterrupted) {
+			continue
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := run(ctx, line); err != nil {
+			fmt.Fprintln(streams.Stderr, err)
+		}
+	}
+}
+
+// lineReader returns a function reading a line from stdin, with the line editor if stdin is a terminal
+func (c *cli) lineReader(streams Streams) func(prompt string) (string, error) {
+	if f, ok := streams.Stdin.(*os.File); ok && lineedit.IsTerminal(int(f.Fd())) {
+		editor := lineedit.New(f, streams.Stdout,
+			lineedit.WithHistory(func() []string {
+				if c.history == nil {
+					return nil
+				}
+				return c.history.Entries()
+			}),
+			lineedit.WithCompleter(c.Complete),
+		)
+		return editor.ReadLine
+	}
+	// the prompt is written for character devices, e.g. terminals where the line editor is not supported,
+	// but not for pipes and files
+	interactive := false
+	if f, ok := streams.Stdin.(*os.File); ok {
+		fi, err := f.Stat()
+		interactive = err == nil && fi.Mode()&os.ModeCharDevice != 0
+	}
+	r := bufio.NewReader(streams.Stdin)
+	return func(prompt string) (string, error) {
+		if interactive {
+			io.WriteString(streams.Stdout, prompt)
+		}
+		line, err := r.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), err
+	}
+}
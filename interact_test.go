@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_cli_Interact(t *testing.T) {
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use: "fail",
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			return errors.New("failed")
+		},
+	})
+	var stdout, stderr strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{
+		Stdin:  strings.NewReader("set a 1\n\nfail\nunknown\r\nset"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err := c.Interact(ctx, "> "); err != nil {
+		t.Fatalf("cli.Interact() error = %v", err)
+	}
+	if got, want := stdout.String(), "a=1\n"; got != want {
+		t.Errorf("cli.Interact() stdout = %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "failed\ncommand unknown not found\n"; got != want {
+		t.Errorf("cli.Interact() stderr = %q, want %q", got, want)
+	}
+}
+
+func Test_cli_Interact_canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx = ContextWithStreams(ctx, Streams{Stdin: strings.NewReader("set a 1\n")})
+	if err := NewCli().Interact(ctx, "> "); !errors.Is(err, context.Canceled) {
+		t.Errorf("cli.Interact() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func Test_cli_InteractWith(t *testing.T) {
+	var stderr strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{
+		Stdin:  strings.NewReader("one\n \ntwo\n"),
+		Stderr: &stderr,
+	})
+	var lines []string
+	err := NewCli().InteractWith(ctx, "> ", func(ctx context.Context, line string) error {
+		lines = append(lines, line)
+		return errors.New("failed " + line)
+	})
+	if err != nil {
+		t.Fatalf("cli.InteractWith() error = %v", err)
+	}
+	if got, want := strings.Join(lines, ","), "one,two"; got != want {
+		t.Errorf("cli.InteractWith() lines = %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "failed one\nfailed two\n"; got != want {
+		t.Errorf("cli.InteractWith() stderr = %q, want %q", got, want)
+	}
+}
+
+func Test_cli_Interact_promptWithoutEditor(t *testing.T) {
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer stdin.Close()
+	if fi, err := stdin.Stat(); err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		t.Skip("null device is not a character device")
+	}
+	var stdout strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdin: stdin, Stdout: &stdout})
+	if err := NewCli().Interact(ctx, "> "); err != nil {
+		t.Fatalf("cli.Interact() error = %v", err)
+	}
+	if got, want := stdout.String(), "> "; got != want {
+		t.Errorf("cli.Interact() stdout = %q, want %q", got, want)
+	}
+}
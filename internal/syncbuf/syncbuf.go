@@ -0,0 +1,25 @@
+// Package syncbuf provides a strings.Builder safe for concurrent writes
+package syncbuf
+
+import (
+	"strings"
+	"sync"
+)
+
+// Builder is a strings.Builder safe for concurrent writes by commands of a pipeline
+type Builder struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *Builder) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *Builder) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
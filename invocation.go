@@ -0,0 +1,174 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Invocation describes a running command. It is passed to Command.Handle
+// and is available to other handlers and hooks through InvocationFrom.
+type Invocation struct {
+	Streams                                // streams of the command, see StreamsFrom
+	Cli     Cli                            // the Cli running the command
+	Path    []*Command                     // commands from the top-level one to the running one
+	Line    string                         // the command line the command is part of, empty for Execute
+	Argv    []string                       // tokens of the command after expansion, starting with its name
+	Flags   map[string]*ParsedCommandFlags // given flags keyed by Type
+	Args    []string                       // positional arguments
+	Bound   any                            // result of Command.Bind, nil without it
+
+	ctx context.Context
+}
+
+type invocationKey struct{}
+
+type observerKey struct{}
+
+// ContextWithObserver returns a copy of ctx in which fn is called with the invocation of every command
+// right before its handler runs. Observers added to a context are called in order.
+// fn may be called concurrently by commands of a pipeline.
+func ContextWithObserver(ctx context.Context, fn func(inv *Invocation)) context.Context {
+	observers, _ := ctx.Value(observerKey{}).([]func(inv *Invocation))
+	observers = append(observers[:len(observers):len(observers)], fn)
+	return context.WithValue(ctx, observerKey{}, observers)
+}
+
+// observe calls observers added to ctx
+func observe(ctx context.Context, inv *Invocation) {
+	observers, _ := ctx.Value(observerKey{}).([]func(inv *Invocation))
+	for _, fn := range observers {
+		fn(inv)
+	}
+}
+
+// InvocationFrom returns the invocation running with ctx, or nil outside of a command
+func InvocationFrom(ctx context.Context) *Invocation {
+	inv, _ := ctx.Value(invocationKey{}).(*Invocation)
+	return inv
+}
+
+// Context returns the context of the invocation, canceled when the command should stop
+func (inv *Invocation) Context() context.Context {
+	return inv.ctx
+}
+
+// Command returns the running command
+func (inv *Invocation) Command() *Command {
+	return inv.Path[len(inv.Path)-1]
+}
+
+// Lookup returns the value of a flag by Type: the given value, else the value of Env, else Default.
+// A bool flag given without a value is "true". It reports false if the flag has no value.
+func (inv *Invocation) Lookup(id string) (string, bool) {
+	flag := inv.flag(id)
+	if parsed, ok := inv.Flags[id]; ok {
+		if parsed.Args == "" && (flag == nil || flag.Kind == "" || flag.Kind == KindBool) {
+			return "true", true
+		}
+		return parsed.Args, true
+	}
+	if flag == nil {
+		return "", false
+	}
+	if v, ok := os.LookupEnv(flag.Env); flag.Env != "" && ok {
+		return v, true
+	}
+	return flag.Default, flag.Default != ""
+}
+
+// String returns the value of a flag, see Lookup
+func (inv *Invocation) String(id string) string {
+	v, _ := inv.Lookup(id)
+	return v
+}
+
+// Bool reports whether a flag is set to a true value, see Lookup
+func (inv *Invocation) Bool(id string) bool {
+	v, _ := inv.Lookup(id)
+	b, _ := strconv.ParseBool(v)
+	return b
+}
+
+// Int returns the value of a flag as an int, 0 if it has no value
+func (inv *Invocation) Int(id string) (int, error) {
+	v, ok := inv.Lookup(id)
+	if !ok {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(v, 0, 0)
+	if err != nil {
+		return 0, inv.invalid(id, err)
+	}
+	return int(n), nil
+}
+
+// Float returns the value of a flag as a float64, 0 if it has no value
+func (inv *Invocation) Float(id string) (float64, error) {
+	v, ok := inv.Lookup(id)
+	if !ok {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, inv.invalid(id, err)
+	}
+	return f, nil
+}
+
+// Duration returns the value of a flag as a time.Duration, 0 if it has no value
+func (inv *Invocation) Duration(id string) (time.Duration, error) {
+	v, ok := inv.Lookup(id)
+	if !ok {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, inv.invalid(id, err)
+	}
+	return d, nil
+}
+
+// Strings returns the comma-separated values of a flag, nil if it has no value
+func (inv *Invocation) Strings(id string) []string {
+	v, ok := inv.Lookup(id)
+	if !ok || v == "" {
+		return nil
+	}
+	return strings.Split(v, ",")
+}
+
+// Decode fills dst, a pointer to a struct tagged as described by Binder, from the flags.
+// Fields are matched with flags of the command by long or short name.
+func (inv *Invocation) Decode(dst any) error {
+	b, err := NewBinder(dst)
+	if err != nil {
+		return err
+	}
+	flags := make(map[string]*ParsedCommandFlags)
+	for _, field := range b.Flags() {
+		for _, f := range inv.Command().Flags {
+			if (field.Long != "" && field.Long == f.Long) || (field.Short != "" && field.Short == f.Short) {
+				if parsed, ok := inv.Flags[f.Type]; ok {
+					flags[field.Type] = parsed
+				}
+			}
+		}
+	}
+	return b.Decode(flags, dst)
+}
+
+func (inv *Invocation) flag(id string) *CommandFlag {
+	return inv.Command().flagByType(id)
+}
+
+func (inv *Invocation) invalid(id string, err error) error {
+	name := "--" + id
+	if flag := inv.flag(id); flag != nil {
+		name = flag.display()
+	}
+	return fmt.Errorf("%w: %s: %v", ErrInvalidFlagValue, name, err)
+}
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_cli_Handle(t *testing.T) {
+	var stdout, stderr strings.Builder
+	c := NewCli(WithStdin(strings.NewReader("input")), WithStdout(&stdout), WithStderr(&stderr))
+	var got *Invocation
+	var hookInv *Invocation
+	c.AddCmd(&Command{
+		Use: "db",
+		PersistentPreRun: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			hookInv = InvocationFrom(ctx)
+			return nil
+		},
+		Subcommands: []*Command{{
+			Use: "migrate",
+			Flags: []*CommandFlag{
+				{Type: "to", Long: "to", Kind: KindInt},
+				{Type: "dry", Long: "dry-run", Kind: KindBool},
+				{Type: "timeout", Long: "timeout", Kind: KindDuration, Default: "1m"},
+				{Type: "tags", Long: "tags", Kind: KindStrings},
+			},
+			Handle: func(inv *Invocation) error {
+				got = inv
+				data := make([]byte, 5)
+				n, _ := inv.Stdin.Read(data)
+				inv.Stdout.Write(data[:n])
+				inv.Stderr.Write([]byte("warning"))
+				return nil
+			},
+		}},
+	})
+
+	line := "db migrate --to=42 --dry-run --tags=a,b x"
+	if err := c.OneCmd(line); err != nil {
+		t.Fatalf("cli.OneCmd() error = %v", err)
+	}
+	if got == nil {
+		t.Fatal("Handle was not called")
+	}
+	if hookInv != got {
+		t.Errorf("InvocationFrom() in a hook = %p, want the invocation passed to Handle %p", hookInv, got)
+	}
+	if stdout.String() != "input" || stderr.String() != "warning" {
+		t.Errorf("streams: stdout %q, stderr %q, want %q and %q", stdout.String(), stderr.String(), "input", "warning")
+	}
+	if got.Cli != c || got.Command().Use != "migrate" || len(got.Path) != 2 || got.Line != line {
+		t.Errorf("invocation = %+v", got)
+	}
+	if want := []string{"db", "migrate", "--to=42", "--dry-run", "--tags=a,b", "x"}; !reflect.DeepEqual(got.Argv, want) {
+		t.Errorf("Invocation.Argv = %q, want %q", got.Argv, want)
+	}
+	if !reflect.DeepEqual(got.Args, []string{"x"}) {
+		t.Errorf("Invocation.Args = %q, want %q", got.Args, []string{"x"})
+	}
+	if InvocationFrom(got.Context()) != got {
+		t.Errorf("InvocationFrom(Invocation.Context()) is not the invocation")
+	}
+
+	if n, err := got.Int("to"); n != 42 || err != nil {
+		t.Errorf("Invocation.Int() = %v, %v, want 42", n, err)
+	}
+	if !got.Bool("dry") {
+		t.Errorf("Invocation.Bool() = false, want true")
+	}
+	if d, err := got.Duration("timeout"); d != time.Minute || err != nil {
+		t.Errorf("Invocation.Duration() = %v, %v, want the default 1m", d, err)
+	}
+	if tags := got.Strings("tags"); !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("Invocation.Strings() = %q", tags)
+	}
+	if v, ok := got.Lookup("missing"); ok || v != "" {
+		t.Errorf("Invocation.Lookup() of an absent flag = %q, %v", v, ok)
+	}
+	var opts struct {
+		To  int  `cli:"to"`
+		Dry bool `cli:"dry-run"`
+	}
+	if err := got.Decode(&opts); err != nil || opts.To != 42 || !opts.Dry {
+		t.Errorf("Invocation.Decode() = %+v, %v", opts, err)
+	}
+}
+
+func TestInvocation_invalidValue(t *testing.T) {
+	inv := &Invocation{
+		Path:  []*Command{{Use: "cmd", Flags: []*CommandFlag{{Type: "n", Short: "n", Kind: KindInt}}}},
+		Flags: map[string]*ParsedCommandFlags{"n": {Type: "n", Name: "n", Args: "many"}},
+	}
+	if _, err := inv.Int("n"); !errors.Is(err, ErrInvalidFlagValue) || !strings.Contains(err.Error(), "-n") {
+		t.Errorf("Invocation.Int() error = %v, want %v for -n", err, ErrInvalidFlagValue)
+	}
+	if InvocationFrom(context.Background()) != nil {
+		t.Errorf("InvocationFrom() outside of a command is not nil")
+	}
+}
+
+func Test_cli_streamsOverride(t *testing.T) {
+	var cliOut, ctxOut strings.Builder
+	c := NewCli(WithStdout(&cliOut))
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &ctxOut})
+	if err := c.RunLine(ctx, "set a 1; set"); err != nil {
+		t.Fatalf("cli.RunLine() error = %v", err)
+	}
+	if cliOut.Len() != 0 || ctxOut.String() != "a=1\n" {
+		t.Errorf("streams in the context must take precedence: cli %q, context %q", cliOut.String(), ctxOut.String())
+	}
+}
+
+func TestContextWithObserver(t *testing.T) {
+	c := NewCli()
+	var ran []string
+	c.AddCmd(
+		&Command{Use: "a", Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+			ran = append(ran, "a")
+		}},
+		&Command{Use: "b", PreRun: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			return errors.New("pre")
+		}, Run: func(flags map[string]*ParsedCommandFlags, args []string) {}},
+	)
+	var observed []string
+	ctx := ContextWithObserver(context.Background(), func(inv *Invocation) {
+		observed = append(observed, "1:"+inv.Command().Use+":"+strings.Join(inv.Args, ","))
+		ran = append(ran, "observer")
+	})
+	ctx = ContextWithObserver(ctx, func(inv *Invocation) {
+		observed = append(observed, "2:"+inv.Command().Use)
+	})
+	if err := c.RunLine(ctx, "a x y; b"); err == nil {
+		t.Fatalf("RunLine() expected the error of the pre hook of b")
+	}
+	if want := []string{"1:a:x,y", "2:a"}; !reflect.DeepEqual(observed, want) {
+		t.Errorf("observed = %q, want %q", observed, want)
+	}
+	if want := []string{"observer", "a"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran = %q, want %q", ran, want)
+	}
+}
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"errors"
+)
+
+// Adds hooks run once before the first command, in the order they were added.
+//
+// If a hook fails, its error is returned by the command that triggered initialization
+// and the command does not run. Initialization resumes from the failed hook on the next command,
+// so hooks that have already succeeded are not run twice.
+// Commands dispatched by a hook must use the context given to it, as other commands wait for init hooks to return.
+func (c *cli) OnInit(hooks ...func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.initHooks = append(c.initHooks, hooks...)
+}
+
+// Adds hooks run by Shutdown. They run in reverse order of addition.
+func (c *cli) OnShutdown(hooks ...func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.shutdownHooks = append(c.shutdownHooks, hooks...)
+}
+
+// Shutdown runs shutdown hooks if at least one command has been dispatched since the previous shutdown.
+//
+// All hooks are run even if some of them fail, the errors are joined.
+// Hooks must tolerate partial initialization, since an init hook may have failed.
+// After Shutdown the next command runs init hooks again.
+// Hooks run without locks held, so they may dispatch commands with the context they are given;
+// such commands do not run init hooks.
+func (c *cli) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if !c.started {
+		c.mu.Unlock()
+		return nil
+	}
+	hooks := append([]func(ctx context.Context) error(nil), c.shutdownHooks...)
+	c.mu.Unlock()
+	ctx = context.WithValue(ctx, lifecycleKey{}, true)
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i](ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.mu.Lock()
+	c.started = false
+	c.initialized = 0
+	c.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// lifecycleKey marks the context of init and shutdown hooks
+type lifecycleKey struct{}
+
+// init runs init hooks that have not completed yet. Hooks run without locks held and may dispatch
+// commands with the context they are given; such commands do not run init hooks.
+// Other commands, e.g. of the same pipeline, wait until the hooks return.
+func (c *cli) init(ctx context.Context) error {
+	if ctx.Value(lifecycleKey{}) != nil {
+		return nil
+	}
+	c.mu.Lock()
+	for c.initDone != nil {
+		done := c.initDone
+		c.mu.Unlock()
+		<-done
+		c.mu.Lock()
+	}
+	c.started = true
+	if c.initialized >= len(c.initHooks) {
+		c.mu.Unlock()
+		return nil
+	}
+	done := make(chan struct{})
+	c.initDone = done
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		c.initDone = nil
+		c.mu.Unlock()
+		close(done)
+	}()
+	ctx = context.WithValue(ctx, lifecycleKey{}, true)
+	for {
+		c.mu.Lock()
+		if c.initialized >= len(c.initHooks) {
+			c.mu.Unlock()
+			return nil
+		}
+		hook := c.initHooks[c.initialized]
+		c.mu.Unlock()
+		if err := hook(ctx); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		c.initialized++
+		c.mu.Unlock()
+	}
+}
+
+// runCommand runs the last command of path with hooks in the following order:
+//
+//  1. init hooks of the Cli (once)
+//  2. PersistentPreRun of every command on path, from the root down
+//  3. PreRun
+//  4. Handle, RunE or Run
+//  5. PostRun
+//  6. PersistentPostRun of every command on path, from the command up to the root
+//
+// The first error stops the chain and is returned, so post hooks only run after a successful handler.
+// The Invocation of the command is available to all of them through InvocationFrom.
+// Command.Bind has already run in Execute, so conversion errors are returned before any of them.
+func (c *cli) runCommand(ctx context.Context, path []*Command, argv []string, flags map[string]*ParsedCommandFlags, args []string, bound any) error {
+	line, _ := ctx.Value(lineKey{}).(string)
+	inv := &Invocation{
+		Streams: StreamsFrom(ctx),
+		Cli:     c,
+		Path:    path,
+		Line:    line,
+		Argv:    argv,
+		Flags:   flags,
+		Args:    args,
+		Bound:   bound,
+	}
+	ctx = context.WithValue(ctx, invocationKey{}, inv)
+	inv.ctx = ctx
+	if err := c.init(ctx); err != nil {
+		return err
+	}
+	cmd := path[len(path)-1]
+	for _, p := range path {
+		if err := runHook(ctx, p.PersistentPreRun, flags, args); err != nil {
+			return err
+		}
+	}
+	if err := runHook(ctx, cmd.PreRun, flags, args); err != nil {
+		return err
+	}
+	observe(ctx, inv)
+	switch {
+	case cmd.Handle != nil:
+		if err := cmd.Handle(inv); err != nil {
+			return err
+		}
+	case cmd.RunE != nil:
+		if err := cmd.RunE(ctx, flags, args); err != nil {
+			return err
+		}
+	default:
+		cmd.Run(flags, args)
+	}
+	if err := runHook(ctx, cmd.PostRun, flags, args); err != nil {
+		return err
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		if err := runHook(ctx, path[i].PersistentPostRun, flags, args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, hook HookFunc, flags map[string]*ParsedCommandFlags, args []string) error {
+	if hook == nil {
+		return nil
+	}
+	return hook(ctx, flags, args)
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_cli_hooks(t *testing.T) {
+	var calls []string
+	hook := func(name string, err error) HookFunc {
+		return func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+	errHook := errors.New("hook failed")
+
+	cli := NewCli()
+	cli.AddCmd(
+		&Command{
+			Use: "db",
+			PersistentPreRun: hook("db.ppre", nil),
+			PersistentPostRun: hook("db.ppost", nil),
+			Subcommands: []*Command{
+				{
+					Use: "migrate",
+					PersistentPreRun: hook("migrate.ppre", nil),
+					PreRun: hook("migrate.pre", nil),
+					PostRun: hook("migrate.post", nil),
+					PersistentPostRun: hook("migrate.ppost", nil),
+					Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+						calls = append(calls, "migrate")
+					},
+				},
+				{
+					Use: "broken",
+					PreRun: hook("broken.pre", errHook),
+					PostRun: hook("broken.post", nil),
+					Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+						calls = append(calls, "broken")
+					},
+				},
+			},
+		},
+	)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "persistent hooks wrap subcommand hooks",
+			input: "db migrate",
+			want: []string{"db.ppre", "migrate.ppre", "migrate.pre", "migrate", "migrate.post", "migrate.ppost", "db.ppost"},
+		},
+		{
+			name: "failed pre hook stops the chain",
+			input: "db broken",
+			want: []string{"db.ppre", "broken.pre"},
+			wantErr: true,
+		},
+		{
+			name: "command without run requires a subcommand",
+			input: "db",
+			want: nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			if err := cli.OneCmd(tt.input); (err != nil) != tt.wantErr {
+				t.Errorf("cli.OneCmd() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("hooks = %v, want %v", calls, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cli_initShutdown(t *testing.T) {
+	var calls []string
+	attempts := 0
+	cli := NewCli()
+	cli.AddCmd(&Command{
+		Use: "do",
+		Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+			calls = append(calls, "do")
+		},
+	})
+	cli.OnInit(
+		func(ctx context.Context) error {
+			calls = append(calls, "init0")
+			return nil
+		},
+		func(ctx context.Context) error {
+			attempts++
+			calls = append(calls, "init1")
+			if attempts == 1 {
+				return errors.New("not ready")
+			}
+			return nil
+		},
+	)
+	cli.OnShutdown(
+		func(ctx context.Context) error {
+			calls = append(calls, "shutdown0")
+			return nil
+		},
+		func(ctx context.Context) error {
+			calls = append(calls, "shutdown1")
+			return errors.New("close failed")
+		},
+	)
+
+	if err := cli.Shutdown(context.Background()); err != nil || calls != nil {
+		t.Fatalf("Shutdown() before any command = %v, calls %v", err, calls)
+	}
+	if err := cli.OneCmd("do"); err == nil {
+		t.Fatalf("OneCmd() must return init error")
+	}
+	if err := cli.OneCmd("do"); err != nil {
+		t.Fatalf("OneCmd() error = %v", err)
+	}
+	if err := cli.OneCmd("do"); err != nil {
+		t.Fatalf("OneCmd() error = %v", err)
+	}
+	if err := cli.Shutdown(context.Background()); err == nil {
+		t.Errorf("Shutdown() must return hook error")
+	}
+	want := []string{"init0", "init1", "init1", "do", "do", "shutdown1", "shutdown0"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func Test_cli_hooksDispatchCommands(t *testing.T) {
+	var ran []string
+	var stderr strings.Builder
+	c := NewCli(WithStderr(&stderr))
+	c.AddCmd(
+		&Command{Use: "warm", Deprecated: &Deprecation{}, Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+			ran = append(ran, "warm")
+		}},
+		&Command{Use: "do", Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+			ran = append(ran, "do")
+		}},
+	)
+	c.OnInit(func(ctx context.Context) error {
+		return c.RunLine(ctx, "warm")
+	})
+	c.OnShutdown(func(ctx context.Context) error {
+		return c.RunLine(ctx, "warm")
+	})
+	done := make(chan error, 1)
+	go func() {
+		if err := c.OneCmd("do"); err != nil {
+			done <- err
+			return
+		}
+		done <- c.Shutdown(context.Background())
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("hooks dispatching commands deadlocked")
+	}
+	if want := []string{"warm", "do", "warm"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran = %q, want %q", ran, want)
+	}
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"warm", "do", "warm"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran after a second Shutdown = %q, want %q", ran, want)
+	}
+}
+
+func Test_cli_initBeforePipeline(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+	c := NewCli()
+	for _, use := range []string{"a", "b"} {
+		c.AddCmd(&Command{Use: use, Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+			record(use)
+		}})
+	}
+	c.OnInit(func(ctx context.Context) error {
+		time.Sleep(50 * time.Millisecond)
+		record("init")
+		return nil
+	})
+	if err := c.OneCmd("a | b"); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[0] != "init" {
+		t.Errorf("events = %q, want init first", events)
+	}
+}
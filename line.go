@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Operators of the command line grammar
+const (
+	opSeq  = ";"  // run the next command unconditionally
+	opAnd  = "&&" // run the next command if the previous one succeeded
+	opOr   = "||" // run the next command if the previous one failed
+	opPipe = "|"  // connect stdout of the previous command to stdin of the next one
+
+	opIn        = "<"   // read stdin from a file
+	opOut       = ">"   // write stdout to a file
+	opAppend    = ">>"  // append stdout to a file
+	opErr       = "2>"  // write stderr to a file
+	opErrAppend = "2>>" // append stderr to a file
+)
+
+// operators are matched longest first
+var operators = []string{opErrAppend, opErr, opAnd, opOr, opAppend, opSeq, opPipe, opIn, opOut}
+
+func isRedirect(op string) bool {
+	return op == opIn || op == opOut || op == opAppend || op == opErr || op == opErrAppend
+}
+
+// wordPart is a piece of a word with the quote that enclosed it
+type wordPart struct {
+	text  string
+	quote rune // 0, '\'' or '"'
+	subst bool // text is a command line of $(...)
+}
+
+// word is a command line word made of adjacent unquoted and quoted parts, such as --flag="a b"
+type word []wordPart
+
+// String returns the text of the word without quotes and expansions
+func (w word) String() string {
+	var b strings.Builder
+	for _, p := range w {
+		if p.subst {
+			b.WriteString("$(" + p.text + ")")
+		} else {
+			b.WriteString(p.text)
+		}
+	}
+	return b.String()
+}
+
+// lexItem is either a word or an operator
+type lexItem struct {
+	op   string
+	word word
+}
+
+// lex splits a command line into words and operators.
+//
+// Quotes work as in Tokenize and protect operators, so 'a;b' is a single word.
+// Adjacent quoted and unquoted text forms one word, e.g. --flag="a b".
+// Command substitutions $(...) are kept whole, outside of quotes and in double quotes.
+func lex(input string) ([]lexItem, error) {
+	var items []lexItem
+	var current word
+	var part strings.Builder
+	inWord := false
+	inQuotes := false
+	quoteRune := rune(0)
+	// endPart adds text collected so far to the current word, quoted parts are kept even if empty
+	endPart := func(quote rune) {
+		if part.Len() > 0 || quote != 0 {
+			current = append(current, wordPart{text: part.String(), quote: quote})
+			part.Reset()
+		}
+	}
+	flush := func() {
+		endPart(0)
+		if inWord {
+			items = append(items, lexItem{word: current})
+			current = nil
+			inWord = false
+		}
+	}
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '(' && (!inQuotes || quoteRune == '"'):
+			end := matchParen(runes, i+2)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed %q at position %d", "$(", i)
+			}
+			quote := rune(0)
+			if inQuotes {
+				quote = quoteRune
+			}
+			endPart(quote)
+			current = append(current, wordPart{text: string(runes[i+2 : end]), quote: quote, subst: true})
+			inWord = true
+			i = end
+		case inQuotes:
+			if r == quoteRune {
+				endPart(quoteRune)
+				inQuotes = false
+			} else {
+				part.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			endPart(0)
+			inQuotes, quoteRune, inWord = true, r, true
+		case unicode.IsSpace(r):
+			flush()
+		case r == '&' && (i+1 >= len(runes) || runes[i+1] != '&'):
+			return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+		case strings.ContainsRune(";&|<>", r) || (r == '2' && !inWord):
+			op := matchOperator(runes[i:])
+			if op == "" {
+				part.WriteRune(r)
+				inWord = true
+				continue
+			}
+			flush()
+			items = append(items, lexItem{op: op})
+			i += len(op) - 1
+		default:
+			part.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unclosed quote at position %d", len(input))
+	}
+	flush()
+	return items, nil
+}
+
+// matchParen returns the index of ')' closing a parenthesis opened before start, skipping quoted text, or -1
+func matchParen(runes []rune, start int) int {
+	depth := 1
+	for i := start; i < len(runes); i++ {
+		switch runes[i] {
+		case '\'', '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != runes[i] {
+				end++
+			}
+			i = end
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// matchOperator returns the operator at the start of runes or an empty string
+func matchOperator(runes []rune) string {
+	rest := string(runes[:min(len(runes), 3)])
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// redirect binds a stream of a command to a file
+type redirect struct {
+	op     string
+	target word
+}
+
+// stage is a command of a pipeline
+type stage struct {
+	argv      []word
+	redirects []redirect
+}
+
+// listItem is a pipeline of a sequential list with the operator that precedes it
+type listItem struct {
+	op     string // empty for the first pipeline
+	stages []stage
+}
+
+// parseList parses a sequential list of the form <pipeline> [(';' | '&&' | '||') <pipeline>]... [';'],
+// where <pipeline> is <command> ['|' <command>]... and every <command> may contain redirections
+// ('<' | '>' | '>>' | '2>' | '2>>') <file>
+func parseList(input string) ([]listItem, error) {
+	items, err := lex(input)
+	if err != nil {
+		return nil, err
+	}
+	var list []listItem
+	current := listItem{}
+	var st stage
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+		switch {
+		case item.op == "":
+			st.argv = append(st.argv, item.word)
+			continue
+		case isRedirect(item.op):
+			if i+1 >= len(items) || items[i+1].op != "" {
+				return nil, fmt.Errorf("syntax error: %q requires a file name", item.op)
+			}
+			st.redirects = append(st.redirects, redirect{op: item.op, target: items[i+1].word})
+			i++
+			continue
+		}
+		if len(st.argv) == 0 {
+			return nil, fmt.Errorf("syntax error near unexpected %q", item.op)
+		}
+		current.stages = append(current.stages, st)
+		st = stage{}
+		if item.op == opPipe {
+			continue
+		}
+		list = append(list, current)
+		current = listItem{op: item.op}
+	}
+	if len(st.argv) > 0 {
+		current.stages = append(current.stages, st)
+		list = append(list, current)
+	} else if len(st.redirects) > 0 || len(current.stages) > 0 || (current.op != "" && current.op != opSeq) {
+		return nil, fmt.Errorf("syntax error: unexpected end of input")
+	}
+	if len(list) == 0 {
+		return nil, usagef("empty input")
+	}
+	return list, nil
+}
+
+// RunLine runs a sequential list of pipelines with shell semantics:
+// ';' always runs the next pipeline, '&&' only if the previous one succeeded and '||' only if it failed.
+// Commands of a pipeline like 'a | b' run concurrently, see StreamsFrom for their streams,
+// and redirections like 'a > file' bind the streams to files, see WithFS and WithFileWriter.
+// Variables like $name and command substitutions like $(cmd) are expanded in every word.
+//
+// The result is the error of the last pipeline that ran, so 'a || b' succeeds if b does.
+//
+// With a History (see WithHistory) references like !! are expanded before anything else,
+// the expanded line is echoed to stderr and the line is recorded in the history.
+func (c *cli) RunLine(ctx context.Context, line string) error {
+	ctx = c.withStreams(ctx)
+	if c.history != nil {
+		expanded, ok, err := c.history.Expand(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			line = expanded
+			fmt.Fprintln(StreamsFrom(ctx).Stderr, line)
+		}
+		if err := c.history.Add(line); err != nil {
+			return err
+		}
+	}
+	return c.runLine(ctx, line)
+}
+
+type lineKey struct{}
+
+// runLine runs a command line as RunLine does, without history
+func (c *cli) runLine(ctx context.Context, line string) error {
+	list, err := parseList(line)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, lineKey{}, line)
+	var status error
+	for _, item := range list {
+		if (item.op == opAnd && status != nil) || (item.op == opOr && status == nil) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		status = c.runPipeline(ctx, item.stages)
+	}
+	return status
+}
@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testListItem is a listItem with words joined to strings and redirections written as op+target
+type testListItem struct {
+	op     string
+	stages [][]string
+}
+
+func flattenList(list []listItem) []testListItem {
+	var items []testListItem
+	for _, item := range list {
+		flat := testListItem{op: item.op}
+		for _, st := range item.stages {
+			var words []string
+			for _, w := range st.argv {
+				words = append(words, w.String())
+			}
+			for _, r := range st.redirects {
+				words = append(words, r.op+r.target.String())
+			}
+			flat.stages = append(flat.stages, words)
+		}
+		items = append(items, flat)
+	}
+	return items
+}
+
+func Test_parseList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []testListItem
+		wantErr bool
+	}{
+		{
+			name: "single command",
+			input: "cmd --flag=\"a b\" 'c d'",
+			want: []testListItem{{stages: [][]string{{"cmd", "--flag=a b", "c d"}}}},
+		},
+		{
+			name: "operators",
+			input: "a 1; b&&c || d;",
+			want: []testListItem{
+				{stages: [][]string{{"a", "1"}}},
+				{op: opSeq, stages: [][]string{{"b"}}},
+				{op: opAnd, stages: [][]string{{"c"}}},
+				{op: opOr, stages: [][]string{{"d"}}},
+			},
+		},
+		{
+			name: "pipelines",
+			input: "a | b x || c|d|e",
+			want: []testListItem{
+				{stages: [][]string{{"a"}, {"b", "x"}}},
+				{op: opOr, stages: [][]string{{"c"}, {"d"}, {"e"}}},
+			},
+		},
+		{
+			name: "trailing pipe",
+			input: "a |",
+			wantErr: true,
+		},
+		{
+			name: "redirections",
+			input: "a <in >out 2>>err x | b >> 'log file' 2> err",
+			want: []testListItem{{stages: [][]string{
+				{"a", "x", "<in", ">out", "2>>err"},
+				{"b", ">>log file", "2>err"},
+			}}},
+		},
+		{
+			name: "digit two is only a redirection at the start of a word",
+			input: "a 2 b2>c",
+			want: []testListItem{{stages: [][]string{{"a", "2", "b2", ">c"}}}},
+		},
+		{
+			name: "redirection without file",
+			input: "a > ; b",
+			wantErr: true,
+		},
+		{
+			name: "quoted operators",
+			input: "a ';' \"&&\" '||' '|' '>' '2>'",
+			want: []testListItem{{stages: [][]string{{"a", ";", "&&", "||", "|", ">", "2>"}}}},
+		},
+		{
+			name: "empty quoted word",
+			input: "a ''",
+			want: []testListItem{{stages: [][]string{{"a", ""}}}},
+		},
+		{
+			name: "leading operator",
+			input: "&& a",
+			wantErr: true,
+		},
+		{
+			name: "trailing operator",
+			input: "a ||",
+			wantErr: true,
+		},
+		{
+			name: "single ampersand",
+			input: "a & b",
+			wantErr: true,
+		},
+		{
+			name: "unclosed quote",
+			input: "a 'b",
+			wantErr: true,
+		},
+		{
+			name: "empty input",
+			input: " ; ",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseList(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseList() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(flattenList(got), tt.want) {
+				t.Errorf("parseList() = %v, want %v", flattenList(got), tt.want)
+			}
+		})
+	}
+}
+
+func Test_lex_quotes(t *testing.T) {
+	items, err := lex(`a"b c"'$d'e ""`)
+	if err != nil {
+		t.Fatalf("lex() error = %v", err)
+	}
+	want := []lexItem{
+		{word: word{{text: "a"}, {text: "b c", quote: '"'}, {text: "$d", quote: '\''}, {text: "e"}}},
+		{word: word{{text: "", quote: '"'}}},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("lex() = %v, want %v", items, want)
+	}
+}
+
+func Test_cli_RunLine(t *testing.T) {
+	var calls []string
+	errFailed := errors.New("failed")
+	c := NewCli()
+	c.AddCmd(
+		&Command{
+			Use: "ok",
+			Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+				calls = append(calls, "ok"+strings.Join(args, ""))
+			},
+		},
+		&Command{
+			Use: "fail",
+			RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+				calls = append(calls, "fail"+strings.Join(args, ""))
+				return errFailed
+			},
+		},
+	)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{name: "sequence continues after failure", input: "fail 1; ok 2", want: []string{"fail1", "ok2"}},
+		{name: "sequence returns last status", input: "ok 1; fail 2", want: []string{"ok1", "fail2"}, wantErr: errFailed},
+		{name: "and runs on success", input: "ok 1 && ok 2", want: []string{"ok1", "ok2"}},
+		{name: "and skips on failure", input: "fail 1 && ok 2", want: []string{"fail1"}, wantErr: errFailed},
+		{name: "or runs on failure", input: "fail 1 || ok 2", want: []string{"fail1", "ok2"}},
+		{name: "or skips on success", input: "ok 1 || fail 2", want: []string{"ok1"}},
+		{name: "mixed", input: "fail 1 && ok 2 || ok 3; ok 4", want: []string{"fail1", "ok3", "ok4"}},
+		{name: "unknown command", input: "missing || ok 1", want: []string{"ok1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			if err := c.RunLine(context.Background(), tt.input); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.RunLine() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("calls = %v, want %v", calls, tt.want)
+			}
+		})
+	}
+}
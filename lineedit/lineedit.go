@@ -0,0 +1,456 @@
+// Package lineedit implements an interactive line editor with Emacs key bindings,
+// history navigation, reverse search and completion.
+package lineedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses Ctrl-C
+var ErrInterrupted = errors.New("interrupted")
+
+// Key codes
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyCtrlG     = 7
+	keyCtrlH     = 8
+	keyTab       = 9
+	keyCtrlJ     = 10
+	keyCtrlK     = 11
+	keyCtrlL     = 12
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlR     = 18
+	keyCtrlT     = 20
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEsc       = 27
+	keyBackspace = 127
+
+	// keys decoded from escape sequences
+	keyUp rune = unicode.MaxRune + 1 + iota
+	keyDown
+	keyRight
+	keyLeft
+	keyHome
+	keyEnd
+	keyDelete
+	keyAltB
+	keyAltF
+	keyAltD
+	keyAltBackspace
+	keyUnknown
+)
+
+// Editor reads lines from a terminal
+type Editor struct {
+	in       *bufio.Reader
+	out      io.Writer
+	fd       int // terminal file descriptor of the input, -1 if it is not a terminal
+	history  func() []string
+	complete func(line string) []string
+}
+
+// Option configures an Editor
+type Option func(e *Editor)
+
+// Sets a function returning history entries, oldest first, for Up/Down and Ctrl-R
+func WithHistory(entries func() []string) Option {
+	return func(e *Editor) {
+		e.history = entries
+	}
+}
+
+// Sets a function returning candidates for the last word of the text before the cursor, used by Tab
+func WithCompleter(complete func(line string) []string) Option {
+	return func(e *Editor) {
+		e.complete = complete
+	}
+}
+
+// New creates an Editor reading keys from in and drawing on out.
+// If in is a terminal (*os.File), it is put into raw mode while ReadLine runs.
+func New(in io.Reader, out io.Writer, opts ...Option) *Editor {
+	e := &Editor{
+		in:  bufio.NewReader(in),
+		out: out,
+		fd:  -1,
+	}
+	if f, ok := in.(*os.File); ok && IsTerminal(int(f.Fd())) {
+		e.fd = int(f.Fd())
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// state of the line being edited
+type state struct {
+	prompt  string
+	buf     []rune
+	pos     int
+	history []string
+	histPos int    // index in history, len(history) for the edited line
+	saved   []rune // edited line while browsing history
+	lastTab bool   // the previous key was Tab without effect
+}
+
+// ReadLine shows prompt and returns the edited line without the line break.
+// It returns io.EOF on Ctrl-D in an empty line and ErrInterrupted on Ctrl-C.
+func (e *Editor) ReadLine(prompt string) (string, error) {
+	if e.fd >= 0 {
+		restore, err := MakeRaw(e.fd)
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+	s := &state{prompt: prompt}
+	if e.history != nil {
+		s.history = e.history()
+	}
+	s.histPos = len(s.history)
+	e.refresh(s)
+	for {
+		key, err := e.readKey()
+		if err != nil {
+			if err == io.EOF && len(s.buf) > 0 {
+				e.write("\r\n")
+				return string(s.buf), nil
+			}
+			return "", err
+		}
+		if key == keyCtrlR {
+			key, err = e.search(s)
+			if err != nil {
+				return "", err
+			}
+		}
+		done, err := e.handle(s, key)
+		if err != nil || done {
+			return string(s.buf), err
+		}
+	}
+}
+
+// handle applies a key to the line and reports whether the line is complete
+func (e *Editor) handle(s *state, key rune) (bool, error) {
+	tab := false
+	switch key {
+	case keyEnter, keyCtrlJ:
+		s.pos = len(s.buf)
+		e.refresh(s)
+		e.write("\r\n")
+		return true, nil
+	case keyCtrlC:
+		e.write("^C\r\n")
+		s.buf = nil
+		return true, ErrInterrupted
+	case keyCtrlD:
+		if len(s.buf) == 0 {
+			e.write("\r\n")
+			return true, io.EOF
+		}
+		s.deleteRange(s.pos, s.pos+1)
+	case keyDelete:
+		s.deleteRange(s.pos, s.pos+1)
+	case keyCtrlA, keyHome:
+		s.pos = 0
+	case keyCtrlE, keyEnd:
+		s.pos = len(s.buf)
+	case keyCtrlB, keyLeft:
+		s.pos = max(0, s.pos-1)
+	case keyCtrlF, keyRight:
+		s.pos = min(len(s.buf), s.pos+1)
+	case keyAltB:
+		s.pos = s.wordStart(s.pos)
+	case keyAltF:
+		s.pos = s.wordEnd(s.pos)
+	case keyCtrlH, keyBackspace:
+		s.deleteRange(s.pos-1, s.pos)
+	case keyCtrlW, keyAltBackspace:
+		s.deleteRange(s.wordStart(s.pos), s.pos)
+	case keyAltD:
+		s.deleteRange(s.pos, s.wordEnd(s.pos))
+	case keyCtrlK:
+		s.deleteRange(s.pos, len(s.buf))
+	case keyCtrlU:
+		s.deleteRange(0, s.pos)
+	case keyCtrlT:
+		if s.pos > 0 && len(s.buf) > 1 {
+			if s.pos == len(s.buf) {
+				s.pos--
+			}
+			s.buf[s.pos-1], s.buf[s.pos] = s.buf[s.pos], s.buf[s.pos-1]
+			s.pos++
+		}
+	case keyCtrlL:
+		e.write("\x1b[H\x1b[2J")
+	case keyCtrlP, keyUp:
+		s.browse(-1)
+	case keyCtrlN, keyDown:
+		s.browse(1)
+	case keyTab:
+		tab = e.completeWord(s)
+	case keyCtrlG, keyEsc, keyUnknown:
+	default:
+		if unicode.IsPrint(key) {
+			s.insert([]rune{key})
+		}
+	}
+	s.lastTab = tab
+	e.refresh(s)
+	return false, nil
+}
+
+// search runs Ctrl-R reverse incremental search and returns the key that ended it
+func (e *Editor) search(s *state) (rune, error) {
+	var query []rune
+	original, originalPos := s.buf, s.pos
+	from := len(s.history) - 1
+	match := -1
+	for {
+		found := ""
+		if match >= 0 {
+			found = s.history[match]
+		}
+		e.write(fmt.Sprintf("\r(reverse-i-search)`%s': %s\x1b[K", string(query), found))
+		key, err := e.readKey()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case key == keyCtrlR:
+			if match >= 0 {
+				from = match - 1
+			}
+		case key == keyCtrlH || key == keyBackspace:
+			if len(query) > 0 {
+				query = query[:len(query)-1]
+			}
+			from = len(s.history) - 1
+		case key == keyCtrlG || key == keyCtrlC:
+			s.buf, s.pos = original, originalPos
+			return keyUnknown, nil
+		case key < keyUp && unicode.IsPrint(key):
+			query = append(query, key)
+			from = len(s.history) - 1
+		default:
+			if match >= 0 {
+				s.buf = []rune(s.history[match])
+				s.pos = len(s.buf)
+				s.histPos = match
+			}
+			return key, nil
+		}
+		match = -1
+		for i := from; i >= 0 && len(query) > 0; i-- {
+			if strings.Contains(s.history[i], string(query)) {
+				match = i
+				break
+			}
+		}
+	}
+}
+
+// completeWord completes the word before the cursor and reports whether Tab had no effect
+func (e *Editor) completeWord(s *state) bool {
+	if e.complete == nil {
+		return false
+	}
+	start := s.pos
+	for start > 0 && !unicode.IsSpace(s.buf[start-1]) {
+		start--
+	}
+	word := string(s.buf[start:s.pos])
+	candidates := e.complete(string(s.buf[:s.pos]))
+	if len(candidates) == 0 {
+		return false
+	}
+	if len(candidates) == 1 {
+		s.deleteRange(start, s.pos)
+		s.insert([]rune(candidates[0] + " "))
+		return false
+	}
+	prefix := commonPrefix(candidates)
+	if len(prefix) > len(word) && strings.HasPrefix(prefix, word) {
+		s.deleteRange(start, s.pos)
+		s.insert([]rune(prefix))
+		return false
+	}
+	if s.lastTab {
+		sorted := append([]string(nil), candidates...)
+		sort.Strings(sorted)
+		e.write("\r\n" + strings.Join(sorted, "  ") + "\r\n")
+	}
+	return true
+}
+
+// commonPrefix returns the longest common prefix of words made of whole runes
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	return prefix
+}
+
+func (s *state) insert(runes []rune) {
+	s.buf = append(s.buf[:s.pos], append(runes, s.buf[s.pos:]...)...)
+	s.pos += len(runes)
+}
+
+// deleteRange removes runes in [from, to) clamped to the line
+func (s *state) deleteRange(from, to int) {
+	from, to = max(0, from), min(len(s.buf), to)
+	if from >= to {
+		return
+	}
+	s.buf = append(s.buf[:from], s.buf[to:]...)
+	if s.pos > to {
+		s.pos -= to - from
+	} else if s.pos > from {
+		s.pos = from
+	}
+}
+
+func (s *state) wordStart(pos int) int {
+	for pos > 0 && !isWordRune(s.buf[pos-1]) {
+		pos--
+	}
+	for pos > 0 && isWordRune(s.buf[pos-1]) {
+		pos--
+	}
+	return pos
+}
+
+func (s *state) wordEnd(pos int) int {
+	for pos < len(s.buf) && !isWordRune(s.buf[pos]) {
+		pos++
+	}
+	for pos < len(s.buf) && isWordRune(s.buf[pos]) {
+		pos++
+	}
+	return pos
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// browse moves through history, keeping the edited line to come back to it
+func (s *state) browse(delta int) {
+	next := s.histPos + delta
+	if next < 0 || next > len(s.history) {
+		return
+	}
+	if s.histPos == len(s.history) {
+		s.saved = s.buf
+	}
+	s.histPos = next
+	if next == len(s.history) {
+		s.buf = s.saved
+	} else {
+		s.buf = []rune(s.history[next])
+	}
+	s.pos = len(s.buf)
+}
+
+// refresh redraws the line and places the cursor
+func (e *Editor) refresh(s *state) {
+	var b strings.Builder
+	b.WriteString("\r")
+	b.WriteString(s.prompt)
+	b.WriteString(string(s.buf))
+	b.WriteString("\x1b[K")
+	if back := len(s.buf) - s.pos; back > 0 {
+		fmt.Fprintf(&b, "\x1b[%dD", back)
+	}
+	e.write(b.String())
+}
+
+func (e *Editor) write(s string) {
+	io.WriteString(e.out, s)
+}
+
+// readKey reads a rune or decodes an escape sequence
+func (e *Editor) readKey() (rune, error) {
+	r, _, err := e.in.ReadRune()
+	if err != nil || r != keyEsc {
+		return r, err
+	}
+	next, _, err := e.in.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	switch next {
+	case 'b':
+		return keyAltB, nil
+	case 'f':
+		return keyAltF, nil
+	case 'd':
+		return keyAltD, nil
+	case keyBackspace, keyCtrlH:
+		return keyAltBackspace, nil
+	case '[', 'O':
+	default:
+		return keyUnknown, nil
+	}
+	var params []rune
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r >= 0x40 && r <= 0x7e {
+			return csiKey(string(params), r), nil
+		}
+		params = append(params, r)
+	}
+}
+
+// csiKey maps the parameters and the final byte of an escape sequence to a key
+func csiKey(params string, final rune) rune {
+	switch final {
+	case 'A':
+		return keyUp
+	case 'B':
+		return keyDown
+	case 'C':
+		return keyRight
+	case 'D':
+		return keyLeft
+	case 'H':
+		return keyHome
+	case 'F':
+		return keyEnd
+	case '~':
+		switch params {
+		case "1", "7":
+			return keyHome
+		case "4", "8":
+			return keyEnd
+		case "3":
+			return keyDelete
+		}
+	}
+	return keyUnknown
+}
@@ -0,0 +1,100 @@
+package lineedit
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEditor_ReadLine(t *testing.T) {
+	history := func() []string {
+		return []string{"db migrate", "echo hello", "db status"}
+	}
+	complete := func(line string) []string {
+		switch {
+		case strings.HasSuffix(line, "st"):
+			return []string{"status", "stop"}
+		case strings.HasSuffix(line, "he"):
+			return []string{"hello"}
+		case strings.HasSuffix(line, "ca"):
+			return []string{"café", "cafè"}
+		}
+		return nil
+	}
+	tests := []struct {
+		name    string
+		keys    string
+		want    string
+		wantErr error
+	}{
+		{name: "plain", keys: "hello\r", want: "hello"},
+		{name: "line feed", keys: "hello\n", want: "hello"},
+		{name: "end of input", keys: "hello", want: "hello"},
+		{name: "backspace", keys: "helx\x7flo\r", want: "hello"},
+		{name: "home and end", keys: "ello\x01h\x05!\r", want: "hello!"},
+		{name: "arrows", keys: "hllo\x1b[D\x1b[D\x1b[De\x1b[C\x1b[C\x1b[C\x1b[C!\r", want: "hello!"},
+		{name: "home and end sequences", keys: "b\x1b[Ha\x1b[Fc\x1bOHx\x1b[D\x1b[3~\r", want: "abc"},
+		{name: "kill to end", keys: "hello world\x01\x06\x06\x06\x06\x06\x0b\r", want: "hello"},
+		{name: "kill to start", keys: "hello world\x1bb\x15\r", want: "world"},
+		{name: "delete word", keys: "echo hello world\x17\x17\r", want: "echo "},
+		{name: "alt delete word", keys: "echo hello world\x01\x1bd\r", want: " hello world"},
+		{name: "word motion", keys: "a b c\x1bb\x1bbX\x1bfY\r", want: "a XbY c"},
+		{name: "transpose", keys: "ab\x14\r", want: "ba"},
+		{name: "delete char", keys: "abc\x01\x04\r", want: "bc"},
+		{name: "unicode", keys: "héllo\x02\x02\x7f\r", want: "hélo"},
+		{name: "history up", keys: "\x1b[A\x1b[A\r", want: "echo hello"},
+		{name: "history ctrl-p ctrl-n", keys: "\x10\x10\x10\x0e\r", want: "echo hello"},
+		{name: "history back to edited line", keys: "draft\x1b[A\x1b[B\r", want: "draft"},
+		{name: "history beyond bounds", keys: "\x1b[B\x1b[A\x1b[A\x1b[A\x1b[A\r", want: "db migrate"},
+		{name: "reverse search", keys: "\x12db\r", want: "db status"},
+		{name: "reverse search again", keys: "\x12db\x12\r", want: "db migrate"},
+		{name: "reverse search then edit", keys: "\x12hel\x05!\r", want: "echo hello!"},
+		{name: "reverse search cancel", keys: "draft\x12db\x07\r", want: "draft"},
+		{name: "complete single", keys: "echo he\t\r", want: "echo hello "},
+		{name: "complete common prefix", keys: "db st\t\r", want: "db st"},
+		{name: "complete multi-byte prefix", keys: "order ca\t\r", want: "order caf"},
+		{name: "complete nothing", keys: "xyz\t\r", want: "xyz"},
+		{name: "eof in empty line", keys: "\x04", wantErr: io.EOF},
+		{name: "interrupt", keys: "abc\x03", wantErr: ErrInterrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			e := New(strings.NewReader(tt.keys), &out, WithHistory(history), WithCompleter(complete))
+			got, err := e.ReadLine("> ")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Editor.ReadLine() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("Editor.ReadLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditor_ReadLine_listCompletions(t *testing.T) {
+	var out strings.Builder
+	e := New(strings.NewReader("st\t\t\r"), &out, WithCompleter(func(line string) []string {
+		return []string{"stop", "status"}
+	}))
+	got, err := e.ReadLine("> ")
+	if err != nil || got != "st" {
+		t.Fatalf("Editor.ReadLine() = %q, %v, want %q", got, err, "st")
+	}
+	if !strings.Contains(out.String(), "\r\nstatus  stop\r\n") {
+		t.Errorf("Editor.ReadLine() output %q does not list candidates", out.String())
+	}
+}
+
+func TestEditor_ReadLine_redraw(t *testing.T) {
+	var out strings.Builder
+	e := New(strings.NewReader("ab\x02\r"), &out)
+	if _, err := e.ReadLine("> "); err != nil {
+		t.Fatalf("Editor.ReadLine() error = %v", err)
+	}
+	want := "\r> \x1b[K" + "\r> a\x1b[K" + "\r> ab\x1b[K" + "\r> ab\x1b[K\x1b[1D" + "\r> ab\x1b[K" + "\r\n"
+	if out.String() != want {
+		t.Errorf("Editor.ReadLine() output = %q, want %q", out.String(), want)
+	}
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+package lineedit
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+func ioctl(fd int, req uintptr, termios *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(termios)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// IsTerminal reports whether fd refers to a terminal
+func IsTerminal(fd int) bool {
+	var termios syscall.Termios
+	return ioctl(fd, syscall.TCGETS, &termios) == nil
+}
+
+// MakeRaw puts the terminal fd into raw mode and returns a function restoring the previous mode.
+// In raw mode input is not echoed nor buffered by lines, and output line breaks must be written as "\r\n".
+func MakeRaw(fd int) (func() error, error) {
+	var old syscall.Termios
+	if err := ioctl(fd, syscall.TCGETS, &old); err != nil {
+		return nil, err
+	}
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, syscall.TCSETS, &raw); err != nil {
+		return nil, err
+	}
+	return func() error {
+		return ioctl(fd, syscall.TCSETS, &old)
+	}, nil
+}
@@ -0,0 +1,97 @@
+//go:build linux
+
+package lineedit
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"unsafe"
+)
+
+// openPty opens a pseudo-terminal pair
+func openPty(t *testing.T) (master, slave *os.File) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	t.Cleanup(func() { master.Close() })
+	var n, unlock uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		t.Skipf("unlock pty: %v", errno)
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, master.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		t.Skipf("get pty number: %v", errno)
+	}
+	slave, err = os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("open pty: %v", err)
+	}
+	t.Cleanup(func() { slave.Close() })
+	return master, slave
+}
+
+func TestEditor_ReadLine_pty(t *testing.T) {
+	master, slave := openPty(t)
+	if !IsTerminal(int(slave.Fd())) {
+		t.Fatal("IsTerminal() = false for a pty")
+	}
+	output := make(chan string, 64)
+	go func() {
+		buf := make([]byte, 256)
+		for {
+			n, err := master.Read(buf)
+			if err != nil {
+				close(output)
+				return
+			}
+			output <- string(buf[:n])
+		}
+	}()
+	e := New(slave, slave, WithHistory(func() []string { return []string{"db status"} }))
+	// readLine types keys once the editor has drawn the prompt, that is the terminal is in raw mode
+	readLine := func(keys string) (string, error) {
+		type result struct {
+			line string
+			err  error
+		}
+		done := make(chan result)
+		go func() {
+			line, err := e.ReadLine("> ")
+			done <- result{line, err}
+		}()
+		for drawn := ""; !strings.Contains(drawn, "> "); {
+			drawn += <-output
+		}
+		if _, err := master.WriteString(keys); err != nil {
+			t.Fatal(err)
+		}
+		for {
+			select {
+			case r := <-done:
+				return r.line, r.err
+			case <-output:
+			}
+		}
+	}
+
+	// keys that the terminal would interpret in cooked mode: ^C, ^U and the arrow keys
+	if _, err := readLine("x\x15\x1b[Ahello\x1b[D\x1b[D\x03"); err != ErrInterrupted {
+		t.Fatalf("Editor.ReadLine() error = %v, want %v", err, ErrInterrupted)
+	}
+	got, err := readLine("\x1b[A!\r")
+	if err != nil || got != "db status!" {
+		t.Fatalf("Editor.ReadLine() = %q, %v, want %q", got, err, "db status!")
+	}
+
+	var termios syscall.Termios
+	if err := ioctl(int(slave.Fd()), syscall.TCGETS, &termios); err != nil {
+		t.Fatal(err)
+	}
+	if termios.Lflag&syscall.ICANON == 0 || termios.Lflag&syscall.ECHO == 0 {
+		t.Errorf("terminal mode is not restored after ReadLine, lflag = %#x", termios.Lflag)
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+
+package lineedit
+
+import "errors"
+
+// ErrNotSupported is returned by MakeRaw on platforms without raw mode support
+var ErrNotSupported = errors.New("raw mode is not supported on this platform")
+
+// IsTerminal reports whether fd refers to a terminal, always false on this platform
+func IsTerminal(fd int) bool {
+	return false
+}
+
+// MakeRaw is not supported on this platform
+func MakeRaw(fd int) (func() error, error) {
+	return nil, ErrNotSupported
+}
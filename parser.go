@@ -10,6 +10,7 @@ import (
 // CommandParser implements a method for parsing commands of the form <command> <flags> <args> into a ParsedCommand structure
 type CommandParser interface {
 	ParseCommand(input string) (*ParsedCommand, error) // ParseCommand parses a string representing a command of the form <command> <flags> <args>
+	ParseTokens(tokens []string) (*ParsedCommand, error) // ParseTokens parses already tokenized input of the form <command> <flags> <args>
 }
 
 type commandParser struct {
@@ -30,6 +31,18 @@ func (cp *commandParser) ParseCommand(input string) (*ParsedCommand, error) {
 	if err != nil {
 		return nil, err
 	}
+	return parseTokens(tokens, true)
+}
+
+// ParseTokens parses tokens produced by Tokenize (or taken from argv as is) into a ParsedCommand structure.
+// The first token is the command name. A '--' token ends the flags, so the following tokens are args.
+// Flag values are taken as is, quotes are not removed.
+func (cp *commandParser) ParseTokens(tokens []string) (*ParsedCommand, error) {
+	return parseTokens(tokens, false)
+}
+
+// parseTokens parses tokens, removing quotes around flag values if trim is set
+func parseTokens(tokens []string, trim bool) (*ParsedCommand, error) {
 	if len(tokens) == 0 {
 		return nil, errors.New("empty input")
 	}
@@ -42,13 +55,20 @@ func (cp *commandParser) ParseCommand(input string) (*ParsedCommand, error) {
 	i := 1
 	for i < len(tokens) {
 		token := tokens[i]
+		if token == "--" {
+			i++
+			break
+		}
 		if strings.HasPrefix(token, "--") {
 			token = token[2:]
 			parts := splitFlag(token)
 			var arg string
 			flag := parts[0]
 			if len(parts) == 2 {
-				arg = trimArg(parts[1])
+				arg = parts[1]
+				if trim {
+					arg = trimArg(arg)
+				}
 			}
 			cmd.Flags[flag] = &ParsedCommandFlags {
 				Name: flag,
@@ -61,7 +81,10 @@ func (cp *commandParser) ParseCommand(input string) (*ParsedCommand, error) {
 				var arg string
 				flag := parts[0]
 				if len(parts) == 2 {
-					arg = trimArg(parts[1])
+					arg = parts[1]
+					if trim {
+						arg = trimArg(arg)
+					}
 				}
 				cmd.Flags[flag] = &ParsedCommandFlags {
 					Name: flag,
@@ -254,6 +254,24 @@ func Test_commandParser_ParseCommand(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "with end of flags",
+			cp:   parser,
+			args: args{
+				input: "cmd --flag1 -- --flag2 -a",
+			},
+			want: &ParsedCommand{
+				Name: "cmd",
+				Flags: map[string]*ParsedCommandFlags{
+					"flag1": {
+						Name: "flag1",
+						Args: "",
+					},
+				},
+				Args: []string{"--flag2", "-a"},
+			},
+			wantErr: false,
+		},
 		{
 			name: "with mixed flags single quotes and double quotes",
 			cp:   parser,
@@ -572,3 +590,29 @@ func Test_commandParser_ParseCommand(t *testing.T) {
 		})
 	}
 }
+
+func Test_commandParser_ParseTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		want   string
+	}{
+		{name: "plain", tokens: []string{"cmd", "--sep=,"}, want: ","},
+		{name: "quoted", tokens: []string{"cmd", `--sep=" "`}, want: `" "`},
+		{name: "single quote", tokens: []string{"cmd", `--sep="`}, want: `"`},
+		{name: "nested quotes", tokens: []string{"cmd", `--sep=""a""`}, want: `""a""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCommandParser().ParseTokens(tt.tokens)
+			if err != nil {
+				t.Fatalf("commandParser.ParseTokens() error = %v", err)
+			}
+			for _, f := range got.Flags {
+				if f.Args != tt.want {
+					t.Errorf("commandParser.ParseTokens() flag value = %q, want %q", f.Args, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// runPipeline runs stages concurrently, connecting the stdout of every stage to the stdin of the next one.
+//
+// Redirections of a stage take precedence over the pipe.
+// A stage that stops reading makes writes of the previous stage fail with io.ErrClosedPipe,
+// which is not treated as a failure. The first failure of a stage cancels the context of the others
+// and is returned. The first stage reads and the last one writes the streams of ctx.
+func (c *cli) runPipeline(ctx context.Context, stages []stage) error {
+	if len(stages) == 1 {
+		return c.runStage(ctx, stages[0], StreamsFrom(ctx))
+	}
+	base := StreamsFrom(ctx)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	n := len(stages)
+	readers := make([]*io.PipeReader, n)
+	writers := make([]*io.PipeWriter, n)
+	for i := 0; i < n-1; i++ {
+		readers[i+1], writers[i] = io.Pipe()
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for i, st := range stages {
+		s := base
+		if readers[i] != nil {
+			s.Stdin = readers[i]
+		}
+		if writers[i] != nil {
+			s.Stdout = writers[i]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.runStage(ctx, st, s)
+			if writers[i] != nil {
+				writers[i].CloseWithError(err)
+			}
+			if readers[i] != nil {
+				readers[i].Close()
+			}
+			if err == nil || errors.Is(err, io.ErrClosedPipe) {
+				return
+			}
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+				cancel(err)
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_cli_pipeline(t *testing.T) {
+	errFailed := errors.New("failed")
+	c := NewCli()
+	c.AddCmd(
+		&Command{
+			Use: "gen",
+			RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+				out := StreamsFrom(ctx).Stdout
+				for i := 0; ; i++ {
+					if len(args) > 0 && fmt.Sprint(i) == args[0] {
+						return nil
+					}
+					if _, err := fmt.Fprintf(out, "line %d\n", i); err != nil {
+						return err
+					}
+				}
+			},
+		},
+		&Command{
+			Use: "upper",
+			RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+				s := StreamsFrom(ctx)
+				data, err := io.ReadAll(s.Stdin)
+				if err != nil {
+					return err
+				}
+				_, err = s.Stdout.Write(bytes.ToUpper(data))
+				return err
+			},
+		},
+		&Command{
+			Use: "head",
+			RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+				s := StreamsFrom(ctx)
+				line, err := bufio.NewReader(s.Stdin).ReadString('\n')
+				if err != nil {
+					return err
+				}
+				_, err = io.WriteString(s.Stdout, line)
+				return err
+			},
+		},
+		&Command{
+			Use: "fail",
+			RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+				return errFailed
+			},
+		},
+		&Command{
+			Use: "wait",
+			RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(5 * time.Second):
+					return errors.New("not canceled")
+				}
+			},
+		},
+	)
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "two stages", input: "gen 2 | upper", want: "LINE 0\nLINE 1\n"},
+		{name: "three stages", input: "gen 3 | upper | head", want: "LINE 0\n"},
+		{name: "reader stops early", input: "gen | head", want: "line 0\n"},
+		{name: "failing stage", input: "gen 2 | fail | upper", wantErr: errFailed},
+		{name: "failure cancels other stages", input: "wait | fail", wantErr: errFailed},
+		{name: "pipeline status in list", input: "fail | upper || gen 1", want: "line 0\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			ctx := ContextWithStreams(context.Background(), Streams{Stdin: strings.NewReader(""), Stdout: &out})
+			if err := c.RunLine(ctx, tt.input); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.RunLine() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && out.String() != tt.want {
+				t.Errorf("output = %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.RunLine(ctx, "wait | wait"); !errors.Is(err, context.Canceled) {
+		t.Errorf("cli.RunLine() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// ErrFileAccessDisabled is returned when a command line or the source command needs a file
+// but the Cli was created with a nil FS or file writer
+var ErrFileAccessDisabled = errors.New("file access is disabled")
+
+// FileWriterFunc opens a file for output redirection, truncating it or appending to it ('>>' and '2>>')
+type FileWriterFunc func(name string, append bool) (io.WriteCloser, error)
+
+// Sets the file system used by input redirection and the source command.
+// By default files are opened with os.Open relative to the working directory, nil disables file reading.
+func WithFS(fsys fs.FS) Option {
+	return func(c *cli) {
+		c.fsys = fsys
+	}
+}
+
+// Sets the function opening files for output redirection.
+// By default files are created with os.OpenFile, nil disables file writing.
+func WithFileWriter(fn FileWriterFunc) Option {
+	return func(c *cli) {
+		c.fileWriter = fn
+	}
+}
+
+// osFS opens files as os.Open does, accepting absolute and relative paths
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func writeOSFile(name string, append bool) (io.WriteCloser, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if append {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	return os.OpenFile(name, flag, 0o666)
+}
+
+// openFile opens a file for reading through the file system of the Cli
+func (c *cli) openFile(name string) (fs.File, error) {
+	if c.fsys == nil {
+		return nil, fmt.Errorf("%s: %w", name, ErrFileAccessDisabled)
+	}
+	return c.fsys.Open(name)
+}
+
+// runStage expands the words of a command and runs it with streams s bound to the files of its redirections
+func (c *cli) runStage(ctx context.Context, st stage, s Streams) (err error) {
+	argv, err := c.expandWords(ctx, st.argv)
+	if err != nil {
+		return err
+	}
+	var closers []io.Closer
+	defer func() {
+		for _, closer := range closers {
+			if closeErr := closer.Close(); err == nil {
+				err = closeErr
+			}
+		}
+	}()
+	for _, r := range st.redirects {
+		target, err := c.expandWord(ctx, r.target)
+		if err != nil {
+			return err
+		}
+		if r.op == opIn {
+			f, err := c.openFile(target)
+			if err != nil {
+				return err
+			}
+			closers = append(closers, f)
+			s.Stdin = f
+			continue
+		}
+		if c.fileWriter == nil {
+			return fmt.Errorf("%s: %w", target, ErrFileAccessDisabled)
+		}
+		w, err := c.fileWriter(target, r.op == opAppend || r.op == opErrAppend)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, w)
+		if r.op == opErr || r.op == opErrAppend {
+			s.Stderr = w
+		} else {
+			s.Stdout = w
+		}
+	}
+	return c.Execute(ContextWithStreams(ctx, s), argv)
+}
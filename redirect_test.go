@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// memFiles collects files written through redirection
+type memFiles map[string]*bytes.Buffer
+
+func (m memFiles) writer(name string, append bool) (io.WriteCloser, error) {
+	if !append || m[name] == nil {
+		m[name] = &bytes.Buffer{}
+	}
+	return nopWriteCloser{m[name]}, nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+func Test_cli_redirect(t *testing.T) {
+	fsys := fstest.MapFS{
+		"in.txt":     {Data: []byte("from file\n")},
+		"script.cli": {Data: []byte("cat < in.txt > sourced.txt\n")},
+	}
+	catCmd := &Command{
+		Use: "cat",
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			s := StreamsFrom(ctx)
+			_, err := io.Copy(s.Stdout, s.Stdin)
+			return err
+		},
+	}
+	warnCmd := &Command{
+		Use: "warn",
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			s := StreamsFrom(ctx)
+			fmt.Fprintln(s.Stdout, "out")
+			fmt.Fprintln(s.Stderr, "err")
+			return nil
+		},
+	}
+
+	tests := []struct {
+		name      string
+		opts      func(files memFiles) []Option
+		input     string
+		wantFiles map[string]string
+		wantOut   string
+		wantErr   error
+	}{
+		{
+			name: "input and output",
+			input: "cat < in.txt > out.txt",
+			wantFiles: map[string]string{"out.txt": "from file\n"},
+		},
+		{
+			name: "append",
+			input: "cat < in.txt > out.txt; cat < in.txt >> out.txt",
+			wantFiles: map[string]string{"out.txt": "from file\nfrom file\n"},
+		},
+		{
+			name: "stderr",
+			input: "warn 2> err.txt",
+			wantFiles: map[string]string{"err.txt": "err\n"},
+			wantOut: "out\n",
+		},
+		{
+			name: "redirection overrides pipe",
+			input: "cat < in.txt > out.txt | cat",
+			wantFiles: map[string]string{"out.txt": "from file\n"},
+		},
+		{
+			name: "source uses the file system",
+			input: "source script.cli",
+			wantFiles: map[string]string{"sourced.txt": "from file\n"},
+		},
+		{
+			name: "missing file",
+			input: "cat < missing.txt",
+			wantErr: fs.ErrNotExist,
+		},
+		{
+			name: "reading disabled",
+			opts: func(files memFiles) []Option {
+				return []Option{WithFS(nil), WithFileWriter(files.writer)}
+			},
+			input: "cat < in.txt",
+			wantErr: ErrFileAccessDisabled,
+		},
+		{
+			name: "writing disabled",
+			opts: func(files memFiles) []Option {
+				return []Option{WithFS(fsys), WithFileWriter(nil)}
+			},
+			input: "warn > out.txt",
+			wantErr: ErrFileAccessDisabled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := memFiles{}
+			opts := []Option{WithFS(fsys), WithFileWriter(files.writer)}
+			if tt.opts != nil {
+				opts = tt.opts(files)
+			}
+			c := NewCli(opts...)
+			c.AddCmd(catCmd, warnCmd)
+
+			var out strings.Builder
+			ctx := ContextWithStreams(context.Background(), Streams{Stdin: strings.NewReader(""), Stdout: &out})
+			if err := c.RunLine(ctx, tt.input); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.RunLine() error = %v, want %v", err, tt.wantErr)
+			}
+			for name, want := range tt.wantFiles {
+				if got := files[name]; got == nil || got.String() != want {
+					t.Errorf("file %s = %v, want %q", name, got, want)
+				}
+			}
+			if out.String() != tt.wantOut {
+				t.Errorf("output = %q, want %q", out.String(), tt.wantOut)
+			}
+		})
+	}
+}
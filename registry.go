@@ -0,0 +1,299 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	ErrDuplicateCommand = errors.New("duplicate command") // a name or an alias is already taken
+	ErrFlagConflict     = errors.New("conflicting flag")  // a flag Type, Long or Short is declared twice
+	ErrInvalidCommand   = errors.New("invalid command")   // a command can not be registered as is
+)
+
+// Adds one or more commands, replacing commands with the same Use
+func (c *cli) AddCmd(commands ...*Command) {
+	c.reg.Lock()
+	defer c.reg.Unlock()
+	for _, cmd := range commands {
+		c.cmds[cmd.Use] = cmd
+	}
+}
+
+// Register adds one or more commands.
+//
+// Unlike AddCmd, it validates commands and their subcommands and rejects
+// names and aliases that are already taken, as well as flags sharing Type, Long or Short within a command.
+// Either all commands are added or none of them.
+func (c *cli) Register(commands ...*Command) error {
+	c.reg.Lock()
+	defer c.reg.Unlock()
+	taken := make(map[string]string)
+	for _, cmd := range c.cmds {
+		for _, name := range cmd.Names() {
+			taken[name] = cmd.Use
+		}
+	}
+	for _, cmd := range commands {
+		if cmd == nil {
+			return fmt.Errorf("%w: nil command", ErrInvalidCommand)
+		}
+		if err := validateCommand(cmd, cmd.Use); err != nil {
+			return err
+		}
+		if err := takeNames(taken, cmd, ""); err != nil {
+			return err
+		}
+	}
+	for _, cmd := range commands {
+		c.cmds[cmd.Use] = cmd
+	}
+	return nil
+}
+
+// ReplaceCmd replaces a registered command with the same Use.
+// The command is validated as in Register, its aliases may not be taken by other commands.
+func (c *cli) ReplaceCmd(cmd *Command) error {
+	if cmd == nil {
+		return fmt.Errorf("%w: nil command", ErrInvalidCommand)
+	}
+	c.reg.Lock()
+	defer c.reg.Unlock()
+	if _, ok := c.cmds[cmd.Use]; !ok {
+		return fmt.Errorf("command %s not found", cmd.Use)
+	}
+	if err := validateCommand(cmd, cmd.Use); err != nil {
+		return err
+	}
+	taken := make(map[string]string)
+	for use, other := range c.cmds {
+		if use == cmd.Use {
+			continue
+		}
+		for _, name := range other.Names() {
+			taken[name] = other.Use
+		}
+	}
+	if err := takeNames(taken, cmd, ""); err != nil {
+		return err
+	}
+	c.cmds[cmd.Use] = cmd
+	return nil
+}
+
+// RemoveCmd removes a command or, if path has several words, a subcommand.
+// Reports whether the command was found. Command lines resolve their path under the same lock,
+// but a command that is already running or whose help is being written may still see the removed subcommand.
+func (c *cli) RemoveCmd(path string) bool {
+	c.reg.Lock()
+	defer c.reg.Unlock()
+	names := strings.Fields(path)
+	if len(names) == 0 {
+		return false
+	}
+	if len(names) == 1 {
+		cmd := c.findLocked(names[0])
+		if cmd == nil {
+			return false
+		}
+		delete(c.cmds, cmd.Use)
+		return true
+	}
+	parent := c.lookupLocked(names[:len(names)-1])
+	if parent == nil {
+		return false
+	}
+	last := names[len(names)-1]
+	for i, sub := range parent.Subcommands {
+		if sub.HasName(last) {
+			parent.Subcommands = append(parent.Subcommands[:i:i], parent.Subcommands[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Returns registered commands sorted by Use
+func (c *cli) Commands() []*Command {
+	c.reg.RLock()
+	defer c.reg.RUnlock()
+	return c.commandsLocked()
+}
+
+// Lookup returns a command by a path of names or aliases separated by spaces, e.g. 'db migrate'.
+// If it does not exist, then nil.
+func (c *cli) Lookup(path string) *Command {
+	c.reg.RLock()
+	defer c.reg.RUnlock()
+	names := strings.Fields(path)
+	if cmd := c.lookupLocked(names); cmd != nil {
+		return cmd
+	}
+	if len(names) > 0 {
+		if builtin, ok := c.builtins[names[0]]; ok {
+			return lookupSubcommand(builtin, names[1:])
+		}
+	}
+	return nil
+}
+
+func (c *cli) commandsLocked() []*Command {
+	cmds := make([]*Command, 0, len(c.cmds))
+	for _, cmd := range c.cmds {
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].Use < cmds[j].Use
+	})
+	return cmds
+}
+
+func (c *cli) lookupLocked(names []string) *Command {
+	if len(names) == 0 {
+		return nil
+	}
+	cmd := c.findLocked(names[0])
+	if cmd == nil {
+		return nil
+	}
+	return lookupSubcommand(cmd, names[1:])
+}
+
+func lookupSubcommand(cmd *Command, names []string) *Command {
+	for _, name := range names {
+		if cmd == nil {
+			return nil
+		}
+		cmd = cmd.GetSubcommand(name)
+	}
+	return cmd
+}
+
+// resolvePath returns the command named by names[0] and its subcommands named by the following names,
+// up to the first name that is not a subcommand, or nil if there is no such command
+func (c *cli) resolvePath(names []string) []*Command {
+	c.reg.RLock()
+	defer c.reg.RUnlock()
+	if len(names) == 0 {
+		return nil
+	}
+	cmd := c.findLocked(names[0])
+	if cmd == nil {
+		cmd = c.builtins[names[0]] // built-in commands are dispatched when no registered command matches
+	}
+	if cmd == nil {
+		return nil
+	}
+	path := []*Command{cmd}
+	for _, name := range names[1:] {
+		if cmd = cmd.GetSubcommand(name); cmd == nil {
+			break
+		}
+		path = append(path, cmd)
+	}
+	return path
+}
+
+func (c *cli) findLocked(name string) *Command {
+	if cmd, ok := c.cmds[name]; ok {
+		return cmd
+	}
+	for _, cmd := range c.commandsLocked() {
+		if cmd.HasName(name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// takeNames records names and aliases of cmd in taken, failing on the first one that is already there
+func takeNames(taken map[string]string, cmd *Command, parent string) error {
+	for _, name := range cmd.Names() {
+		if owner, ok := taken[name]; ok {
+			return fmt.Errorf("%w: %s is already used by %s", ErrDuplicateCommand, strings.TrimSpace(parent+" "+name), owner)
+		}
+		taken[name] = strings.TrimSpace(parent + " " + cmd.Use)
+	}
+	return nil
+}
+
+// validateCommand checks a command and its subcommands
+func validateCommand(cmd *Command, path string) error {
+	if cmd.Use == "" || strings.ContainsFunc(cmd.Use, unicode.IsSpace) {
+		return fmt.Errorf("%w: %q is not a valid name", ErrInvalidCommand, path)
+	}
+	for _, alias := range cmd.Aliases {
+		if alias == "" || strings.ContainsFunc(alias, unicode.IsSpace) {
+			return fmt.Errorf("%w: %s: alias %q is not a valid name", ErrInvalidCommand, path, alias)
+		}
+	}
+	types := make(map[string]bool)
+	longs := make(map[string]bool)
+	shorts := make(map[string]bool)
+	for _, f := range cmd.Flags {
+		if f == nil {
+			return fmt.Errorf("%w: %s: nil flag", ErrInvalidCommand, path)
+		}
+		if f.Long == "" && f.Short == "" {
+			return fmt.Errorf("%w: %s: flag %q has neither Long nor Short", ErrInvalidCommand, path, f.Type)
+		}
+		if types[f.Type] {
+			return fmt.Errorf("%w: %s: type %q is declared twice", ErrFlagConflict, path, f.Type)
+		}
+		types[f.Type] = true
+		if f.Long != "" {
+			if longs[f.Long] {
+				return fmt.Errorf("%w: %s: --%s is declared twice", ErrFlagConflict, path, f.Long)
+			}
+			longs[f.Long] = true
+		}
+		if f.Short != "" {
+			if shorts[f.Short] {
+				return fmt.Errorf("%w: %s: -%s is declared twice", ErrFlagConflict, path, f.Short)
+			}
+			shorts[f.Short] = true
+		}
+	}
+	if err := validateFlagGroups(cmd, path); err != nil {
+		return err
+	}
+	for _, f := range cmd.Flags {
+		if !f.Negatable {
+			continue
+		}
+		if f.Long == "" || (f.Kind != "" && f.Kind != KindBool) {
+			return fmt.Errorf("%w: %s: negatable flag %q must be a bool flag with Long", ErrInvalidCommand, path, f.Type)
+		}
+		if longs[negationPrefix+f.Long] {
+			return fmt.Errorf("%w: %s: --%s%s is declared twice", ErrFlagConflict, path, negationPrefix, f.Long)
+		}
+	}
+	optional := false
+	for i, arg := range cmd.Args {
+		switch {
+		case arg == nil || arg.Name == "":
+			return fmt.Errorf("%w: %s: argument %d has no name", ErrInvalidCommand, path, i+1)
+		case arg.Variadic && i < len(cmd.Args)-1:
+			return fmt.Errorf("%w: %s: only the last argument may be variadic", ErrInvalidCommand, path)
+		case arg.Required && optional:
+			return fmt.Errorf("%w: %s: required argument %q follows an optional one", ErrInvalidCommand, path, arg.Name)
+		}
+		optional = optional || !arg.Required
+	}
+	taken := make(map[string]string)
+	for _, sub := range cmd.Subcommands {
+		if sub == nil {
+			return fmt.Errorf("%w: %s: nil subcommand", ErrInvalidCommand, path)
+		}
+		if err := validateCommand(sub, path+" "+sub.Use); err != nil {
+			return err
+		}
+		if err := takeNames(taken, sub, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
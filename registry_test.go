@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func Test_cli_Register(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing []*Command
+		cmds     []*Command
+		wantErr  error
+	}{
+		{
+			name: "new commands",
+			existing: []*Command{{Use: "do"}},
+			cmds: []*Command{{Use: "other", Aliases: []string{"o"}}},
+		},
+		{
+			name: "duplicate use",
+			existing: []*Command{{Use: "do"}},
+			cmds: []*Command{{Use: "do"}},
+			wantErr: ErrDuplicateCommand,
+		},
+		{
+			name: "alias taken by existing command",
+			existing: []*Command{{Use: "do", Aliases: []string{"d"}}},
+			cmds: []*Command{{Use: "other", Aliases: []string{"d"}}},
+			wantErr: ErrDuplicateCommand,
+		},
+		{
+			name: "duplicate within registered commands",
+			cmds: []*Command{{Use: "do"}, {Use: "other", Aliases: []string{"do"}}},
+			wantErr: ErrDuplicateCommand,
+		},
+		{
+			name: "duplicate subcommands",
+			cmds: []*Command{{Use: "db", Subcommands: []*Command{{Use: "migrate", Aliases: []string{"m"}}, {Use: "m"}}}},
+			wantErr: ErrDuplicateCommand,
+		},
+		{
+			name: "conflicting long flag",
+			cmds: []*Command{{Use: "do", Flags: []*CommandFlag{{Type: "a", Long: "flag"}, {Type: "b", Long: "flag"}}}},
+			wantErr: ErrFlagConflict,
+		},
+		{
+			name: "conflicting short flag",
+			cmds: []*Command{{Use: "do", Flags: []*CommandFlag{{Type: "a", Short: "f"}, {Type: "b", Short: "f"}}}},
+			wantErr: ErrFlagConflict,
+		},
+		{
+			name: "conflicting flag type",
+			cmds: []*Command{{Use: "do", Flags: []*CommandFlag{{Type: "a", Long: "one"}, {Type: "a", Long: "two"}}}},
+			wantErr: ErrFlagConflict,
+		},
+		{
+			name: "negatable flag conflicts with long flag",
+			cmds: []*Command{{Use: "do", Flags: []*CommandFlag{{Type: "a", Long: "no-color"}, {Type: "b", Long: "color", Negatable: true}}}},
+			wantErr: ErrFlagConflict,
+		},
+		{
+			name: "negatable flag without long name",
+			cmds: []*Command{{Use: "do", Flags: []*CommandFlag{{Type: "a", Short: "c", Negatable: true}}}},
+			wantErr: ErrInvalidCommand,
+		},
+		{
+			name: "negatable flag with a value",
+			cmds: []*Command{{Use: "do", Flags: []*CommandFlag{{Type: "a", Long: "level", Kind: KindInt, Negatable: true}}}},
+			wantErr: ErrInvalidCommand,
+		},
+		{
+			name: "invalid name",
+			cmds: []*Command{{Use: "do it"}},
+			wantErr: ErrInvalidCommand,
+		},
+		{
+			name: "variadic argument not last",
+			cmds: []*Command{{Use: "do", Args: []*CommandArg{{Name: "a", Variadic: true}, {Name: "b"}}}},
+			wantErr: ErrInvalidCommand,
+		},
+		{
+			name: "required argument after optional",
+			cmds: []*Command{{Use: "do", Args: []*CommandArg{{Name: "a"}, {Name: "b", Required: true}}}},
+			wantErr: ErrInvalidCommand,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCli()
+			c.AddCmd(tt.existing...)
+			err := c.Register(tt.cmds...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.Register() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil && len(c.Commands()) != len(tt.existing) {
+				t.Errorf("cli.Register() must not add commands on error")
+			}
+		})
+	}
+}
+
+func Test_cli_registry(t *testing.T) {
+	c := NewCli()
+	migrate := &Command{Use: "migrate", Aliases: []string{"m"}}
+	db := &Command{Use: "db", Subcommands: []*Command{migrate, {Use: "seed"}}}
+	if err := c.Register(&Command{Use: "do"}, db); err != nil {
+		t.Fatalf("cli.Register() error = %v", err)
+	}
+
+	var names []string
+	for _, cmd := range c.Commands() {
+		names = append(names, cmd.Use)
+	}
+	if want := []string{"db", "do"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("cli.Commands() = %v, want %v", names, want)
+	}
+	if got := c.Lookup("db m"); got != migrate {
+		t.Errorf("cli.Lookup() = %v, want %v", got, migrate)
+	}
+	if got := c.Lookup("db nothing"); got != nil {
+		t.Errorf("cli.Lookup() = %v, want nil", got)
+	}
+
+	if err := c.ReplaceCmd(&Command{Use: "nothing"}); err == nil {
+		t.Errorf("cli.ReplaceCmd() must fail for unknown command")
+	}
+	replaced := &Command{Use: "do", Aliases: []string{"d"}}
+	if err := c.ReplaceCmd(replaced); err != nil {
+		t.Errorf("cli.ReplaceCmd() error = %v", err)
+	}
+	if got := c.Lookup("d"); got != replaced {
+		t.Errorf("cli.Lookup() = %v, want %v", got, replaced)
+	}
+
+	if !c.RemoveCmd("db migrate") || c.Lookup("db migrate") != nil || c.Lookup("db seed") == nil {
+		t.Errorf("cli.RemoveCmd() must remove only the subcommand")
+	}
+	if !c.RemoveCmd("d") || c.Lookup("do") != nil {
+		t.Errorf("cli.RemoveCmd() must remove a command by alias")
+	}
+	if c.RemoveCmd("do") {
+		t.Errorf("cli.RemoveCmd() must report missing command")
+	}
+}
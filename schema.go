@@ -0,0 +1,125 @@
+package cli
+
+// SchemaVersion is incremented when fields of Schema change incompatibly
+const SchemaVersion = 1
+
+// Schema is a serializable description of registered commands, see Cli.Schema.
+// It is encoded to JSON with field names in lower camel case; empty optional fields are omitted.
+type Schema struct {
+	Version  int             `json:"version"`
+	Groups   []CommandGroup  `json:"groups,omitempty"` // see WithGroups
+	Commands []CommandSchema `json:"commands"`
+}
+
+// CommandSchema describes a command
+type CommandSchema struct {
+	Use         string            `json:"use"`
+	Path        string            `json:"path"` // names from the top-level command, e.g. 'db migrate'
+	Aliases     []string          `json:"aliases,omitempty"`
+	Desc        string            `json:"description,omitempty"`
+	LongDesc    string            `json:"longDescription,omitempty"`
+	Example     string            `json:"example,omitempty"`
+	Runnable    bool              `json:"runnable"` // false if the command only groups subcommands
+	Deprecated  *Deprecation      `json:"deprecated,omitempty"`
+	Group       string            `json:"group,omitempty"`
+	Flags       []FlagSchema      `json:"flags,omitempty"`
+	FlagGroups  []FlagGroupSchema `json:"flagGroups,omitempty"`
+	Args        []ArgSchema       `json:"args,omitempty"`
+	Subcommands []CommandSchema   `json:"subcommands,omitempty"`
+}
+
+// FlagSchema describes a flag
+type FlagSchema struct {
+	ID        string   `json:"id"` // Type of the CommandFlag
+	Long      string   `json:"long,omitempty"`
+	Short     string   `json:"short,omitempty"`
+	Kind      FlagKind `json:"kind,omitempty"`
+	Default   string   `json:"default,omitempty"`
+	Env       string   `json:"env,omitempty"`
+	Required  bool     `json:"required"`
+	Negatable bool     `json:"negatable,omitempty"` // also accepted as '--no-<long>'
+	Choices   []string `json:"choices,omitempty"`
+	Desc      string   `json:"description,omitempty"`
+	LongDesc  string   `json:"longDescription,omitempty"`
+
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+}
+
+// FlagGroupSchema describes a rule over flags
+type FlagGroupSchema struct {
+	Kind  FlagGroupKind `json:"kind"`
+	Flags []string      `json:"flags"` // ids of the flags
+}
+
+// ArgSchema describes a positional argument
+type ArgSchema struct {
+	Name     string   `json:"name"`
+	Desc     string   `json:"description,omitempty"`
+	LongDesc string   `json:"longDescription,omitempty"`
+	Required bool     `json:"required"`
+	Variadic bool     `json:"variadic"`
+	Choices  []string `json:"choices,omitempty"`
+}
+
+// Schema describes registered commands sorted by Use, with subcommands, flags and arguments in declaration order.
+// Built-in and hidden commands and hidden flags are not included.
+func (c *cli) Schema() Schema {
+	s := Schema{Version: SchemaVersion, Groups: c.groups, Commands: []CommandSchema{}}
+	for _, cmd := range c.Commands() {
+		if cmd.Hidden {
+			continue
+		}
+		s.Commands = append(s.Commands, commandSchema(cmd, cmd.Use))
+	}
+	return s
+}
+
+func commandSchema(cmd *Command, path string) CommandSchema {
+	s := CommandSchema{
+		Use:        cmd.Use,
+		Path:       path,
+		Aliases:    cmd.Aliases,
+		Desc:       cmd.Desc.Short,
+		LongDesc:   cmd.Desc.Long,
+		Example:    cmd.Example,
+		Runnable:   cmd.Runnable(),
+		Deprecated: cmd.Deprecated,
+		Group:      cmd.Group,
+	}
+	for _, f := range cmd.VisibleFlags() {
+		s.Flags = append(s.Flags, FlagSchema{
+			ID:         f.Type,
+			Long:       f.Long,
+			Short:      f.Short,
+			Kind:       f.Kind,
+			Default:    f.Default,
+			Env:        f.Env,
+			Required:   f.Required,
+			Negatable:  f.Negatable,
+			Choices:    f.Choices,
+			Desc:       f.Desc.Short,
+			LongDesc:   f.Desc.Long,
+			Deprecated: f.Deprecated,
+		})
+	}
+	for _, g := range cmd.FlagGroups {
+		if g == nil {
+			continue
+		}
+		s.FlagGroups = append(s.FlagGroups, FlagGroupSchema{Kind: g.Kind, Flags: g.Flags})
+	}
+	for _, arg := range cmd.Args {
+		s.Args = append(s.Args, ArgSchema{
+			Name:     arg.Name,
+			Desc:     arg.Desc.Short,
+			LongDesc: arg.Desc.Long,
+			Required: arg.Required,
+			Variadic: arg.Variadic,
+			Choices:  arg.Choices,
+		})
+	}
+	for _, sub := range cmd.VisibleSubcommands() {
+		s.Subcommands = append(s.Subcommands, commandSchema(sub, path+" "+sub.Use))
+	}
+	return s
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func Test_cli_Schema(t *testing.T) {
+	const want = `{
+  "version": 1,
+  "commands": [
+    {
+      "use": "db",
+      "path": "db",
+      "description": "Manages the database",
+      "runnable": false,
+      "subcommands": [
+        {
+          "use": "migrate",
+          "path": "db migrate",
+          "aliases": [
+            "m"
+          ],
+          "description": "Applies migrations",
+          "longDescription": "Applies pending migrations in order.",
+          "example": "db migrate 3\ndb m --to=42 1",
+          "runnable": true,
+          "flags": [
+            {
+              "id": "dry",
+              "long": "dry-run",
+              "short": "n",
+              "kind": "bool",
+              "required": false,
+              "description": "Prints SQL"
+            },
+            {
+              "id": "to",
+              "long": "to",
+              "kind": "int",
+              "default": "0",
+              "required": false,
+              "description": "Target version"
+            },
+            {
+              "id": "user",
+              "long": "user",
+              "kind": "string",
+              "env": "CLI_TEST_DB_USER",
+              "required": true
+            }
+          ],
+          "args": [
+            {
+              "name": "steps",
+              "description": "Number of migrations",
+              "required": true,
+              "variadic": false
+            },
+            {
+              "name": "files",
+              "required": false,
+              "variadic": true
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+	c := helpTestCli()
+	data, err := json.MarshalIndent(c.Schema(), "", "  ")
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if got := string(data) + "\n"; got != want {
+		t.Errorf("cli.Schema() JSON =\n%s\nwant\n%s", got, want)
+	}
+
+	var out strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+	if err := c.Execute(ctx, []string{"--help-json"}); err != nil {
+		t.Fatalf("cli.Execute(--help-json) error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("cli.Execute(--help-json) output =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := c.Execute(ctx, []string{"db", "migrate", "--help-json"}); err != nil {
+		t.Fatalf("cli.Execute(db migrate --help-json) error = %v", err)
+	}
+	var cmd CommandSchema
+	if err := json.Unmarshal([]byte(out.String()), &cmd); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if cmd.Path != "db migrate" || len(cmd.Flags) != 3 || len(cmd.Args) != 2 {
+		t.Errorf("cli.Execute(db migrate --help-json) = %+v", cmd)
+	}
+}
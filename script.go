@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ScriptPolicy defines what RunScript does when a command fails
+type ScriptPolicy int
+
+const (
+	StopOnError     ScriptPolicy = iota // return the first error
+	ContinueOnError                     // run the remaining commands and return all errors joined
+)
+
+// maxSourceDepth limits nesting of the source command
+const maxSourceDepth = 32
+
+// ScriptError reports a failed script command with its location
+type ScriptError struct {
+	File string
+	Line int // line where the command starts
+	Err  error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// Sets what RunScript and the source command do when a command fails, StopOnError by default
+func WithScriptPolicy(policy ScriptPolicy) Option {
+	return func(c *cli) {
+		c.scriptPolicy = policy
+	}
+}
+
+// RunScript runs command lines read from r, see RunLine.
+//
+// Blank lines are skipped, '#' at the start of a word begins a comment running to the end of the line.
+// A backslash at the end of a line joins the line with the next one,
+// and quoted strings may span several lines keeping the line breaks.
+// Errors are returned as *ScriptError with the name of r (if it has a Name method like *os.File) and the line number.
+func (c *cli) RunScript(ctx context.Context, r io.Reader) error {
+	name := "script"
+	if named, ok := r.(interface{ Name() string }); ok {
+		name = named.Name()
+	}
+	return c.runScript(c.withStreams(ctx), name, r)
+}
+
+func (c *cli) runScript(ctx context.Context, name string, r io.Reader) error {
+	var errs []error
+	s := newScriptScanner(r)
+	for {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		stmt, line, err := s.next()
+		if err == io.EOF {
+			break
+		}
+		if err == nil {
+			err = c.runLine(ctx, stmt)
+		}
+		if err == nil {
+			continue
+		}
+		errs = append(errs, &ScriptError{File: name, Line: line, Err: err})
+		if c.scriptPolicy == StopOnError || s.err != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// scriptScanner splits script input into statements
+type scriptScanner struct {
+	lines  *bufio.Scanner
+	lineNo int
+	err    error // read error, stops scanning
+}
+
+func newScriptScanner(r io.Reader) *scriptScanner {
+	lines := bufio.NewScanner(r)
+	lines.Buffer(nil, 1024*1024)
+	return &scriptScanner{lines: lines}
+}
+
+// next returns the next non-empty statement and the line it starts on, io.EOF at the end of input
+func (s *scriptScanner) next() (string, int, error) {
+	var stmt strings.Builder
+	start := 0
+	inQuotes := false
+	quoteRune := rune(0)
+	for s.lines.Scan() {
+		s.lineNo++
+		if start == 0 {
+			start = s.lineNo
+		}
+		line := s.lines.Text()
+		continued := false
+		prev := rune(' ')
+		if text := stmt.String(); text != "" {
+			prev, _ = utf8.DecodeLastRuneInString(text)
+		}
+	runes:
+		for i, r := range line {
+			switch {
+			case r == '\'' || r == '"':
+				if !inQuotes {
+					inQuotes, quoteRune = true, r
+				} else if r == quoteRune {
+					inQuotes = false
+				}
+			case r == '#' && !inQuotes && unicode.IsSpace(prev):
+				break runes
+			case r == '\\' && !inQuotes && strings.TrimSpace(line[i+1:]) == "":
+				continued = true
+				break runes
+			}
+			stmt.WriteRune(r)
+			prev = r
+		}
+		if inQuotes {
+			stmt.WriteByte('\n')
+			continue
+		}
+		if continued {
+			continue
+		}
+		if text := strings.TrimSpace(stmt.String()); text != "" {
+			return text, start, nil
+		}
+		stmt.Reset()
+		start = 0
+	}
+	if err := s.lines.Err(); err != nil {
+		s.err = err
+		return "", s.lineNo, err
+	}
+	if inQuotes {
+		return "", start, errors.New("unclosed quote")
+	}
+	if text := strings.TrimSpace(stmt.String()); text != "" {
+		return text, start, nil
+	}
+	return "", s.lineNo, io.EOF
+}
+
+func (c *cli) sourceCommand() *Command {
+	return &Command{
+		Use: "source",
+		Desc: Description{
+			Short: "Runs commands from a script file",
+			Long:  "Runs commands from a script file in the current session. See RunScript for the file syntax.",
+		},
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			if len(args) != 1 {
+				return usagef("usage: source <file>")
+			}
+			depth, _ := ctx.Value(sourceDepthKey{}).(int)
+			if depth >= maxSourceDepth {
+				return fmt.Errorf("source: nesting exceeds %d levels", maxSourceDepth)
+			}
+			f, err := c.openFile(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			ctx = context.WithValue(ctx, sourceDepthKey{}, depth+1)
+			return c.runScript(ctx, args[0], f)
+		},
+	}
+}
+
+type sourceDepthKey struct{}
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_cli_RunScript(t *testing.T) {
+	var calls [][]string
+	newCli := func(opts ...Option) Cli {
+		c := NewCli(opts...)
+		c.AddCmd(
+			&Command{
+				Use: "do",
+				Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+					calls = append(calls, append([]string{"do"}, args...))
+				},
+			},
+			&Command{
+				Use: "fail",
+				RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+					calls = append(calls, []string{"fail"})
+					return errors.New("failed")
+				},
+			},
+		)
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		script    string
+		want      [][]string
+		wantLines []int
+	}{
+		{
+			name: "comments and blank lines",
+			script: "# header\n\ndo a # trailing\ndo 'not # comment' b#c\n",
+			want: [][]string{{"do", "a"}, {"do", "not # comment", "b#c"}},
+		},
+		{
+			name: "line continuation",
+			script: "do a \\\n  b \\\n  c\ndo d",
+			want: [][]string{{"do", "a", "b", "c"}, {"do", "d"}},
+		},
+		{
+			name: "multi-line quoted string",
+			script: "do \"first\n# kept\nthird\"\ndo x",
+			want: [][]string{{"do", "first\n# kept\nthird"}, {"do", "x"}},
+		},
+		{
+			name: "stop on error",
+			script: "do a\nfail\ndo b\n",
+			want: [][]string{{"do", "a"}, {"fail"}},
+			wantLines: []int{2},
+		},
+		{
+			name: "continue on error",
+			opts: []Option{WithScriptPolicy(ContinueOnError)},
+			script: "fail\nmissing\ndo b\n",
+			want: [][]string{{"fail"}, {"do", "b"}},
+			wantLines: []int{1, 2},
+		},
+		{
+			name: "unclosed quote",
+			script: "do a\ndo 'b\n\n",
+			want: [][]string{{"do", "a"}},
+			wantLines: []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			err := newCli(tt.opts...).RunScript(context.Background(), strings.NewReader(tt.script))
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Errorf("calls = %q, want %q", calls, tt.want)
+			}
+			var lines []int
+			for _, e := range unwrapAll(err) {
+				var scriptErr *ScriptError
+				if !errors.As(e, &scriptErr) {
+					t.Fatalf("error %v is not a ScriptError", e)
+				}
+				lines = append(lines, scriptErr.Line)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("error lines = %v, want %v (%v)", lines, tt.wantLines, err)
+			}
+		})
+	}
+}
+
+func Test_cli_source(t *testing.T) {
+	var calls []string
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use: "do",
+		Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+			calls = append(calls, args...)
+		},
+	})
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "inner.cli")
+	outer := filepath.Join(dir, "outer.cli")
+	os.WriteFile(inner, []byte("do inner\nmissing\n"), 0o644)
+	os.WriteFile(outer, []byte("do outer\nsource '"+inner+"'\n"), 0o644)
+
+	err := c.OneCmd("source '" + outer + "'")
+	if want := []string{"outer", "inner"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if err == nil || !strings.Contains(err.Error(), inner+":2:") || !strings.Contains(err.Error(), outer+":2:") {
+		t.Errorf("source error = %v, want locations in both files", err)
+	}
+}
+
+// unwrapAll flattens errors joined with errors.Join
+func unwrapAll(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
@@ -0,0 +1,449 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// ErrInvalidSpec is wrapped by SpecError when a spec can not be turned into commands
+var ErrInvalidSpec = errors.New("invalid spec")
+
+// Handlers are Go handlers that spec commands refer to by name
+type Handlers map[string]func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error
+
+// SpecError reports an invalid spec with its location
+type SpecError struct {
+	File   string // empty if the spec is not read from a file
+	Path   string // location in the spec, e.g. 'commands[0].flags[1].kind'
+	Line   int    // 1-based, 0 if unknown
+	Column int    // 1-based byte offset in the line, 0 if unknown
+	Err    error
+}
+
+func (e *SpecError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File + ":")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&b, "%d:%d:", e.Line, e.Column)
+	}
+	if e.Path != "" {
+		b.WriteString(" " + e.Path + ":")
+	}
+	return strings.TrimSpace(b.String() + " " + e.Err.Error())
+}
+
+func (e *SpecError) Unwrap() error {
+	return e.Err
+}
+
+// specFile is the top-level object of a spec
+type specFile struct {
+	Commands []specCommand `json:"commands"`
+}
+
+// specCommand is a command of a spec, its fields are named as in CommandSchema
+type specCommand struct {
+	Use         string            `json:"use"`
+	Aliases     []string          `json:"aliases"`
+	Desc        string            `json:"description"`
+	LongDesc    string            `json:"longDescription"`
+	Example     string            `json:"example"`
+	Deprecated  *Deprecation      `json:"deprecated"`
+	Hidden      bool              `json:"hidden"`
+	Group       string            `json:"group"`
+	Handler     string            `json:"handler"` // name in Handlers
+	Exec        []string          `json:"exec"`    // argv templates of an external program
+	Flags       []specFlag        `json:"flags"`
+	FlagGroups  []FlagGroupSchema `json:"flagGroups"`
+	Args        []ArgSchema       `json:"args"`
+	Subcommands []specCommand     `json:"subcommands"`
+}
+
+// specFlag is a flag of a spec. Hidden flags are not in Schema, so Hidden is not a field of FlagSchema.
+type specFlag struct {
+	FlagSchema
+	Hidden bool `json:"hidden"`
+}
+
+// LoadSpecFile loads commands from the JSON spec file at path, see LoadSpec
+func LoadSpecFile(path string, handlers Handlers) ([]*Command, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cmds, err := loadSpec(data, handlers)
+	var specErr *SpecError
+	if errors.As(err, &specErr) {
+		specErr.File = path
+	}
+	return cmds, err
+}
+
+// LoadSpec loads commands from a JSON spec. The spec lists commands with the fields of CommandSchema
+// and binds every runnable command either to a Go handler by name or to an external program:
+//
+//	{"commands": [
+//		{"use": "greet", "handler": "greet", "flags": [{"id": "name", "long": "name", "kind": "string"}]},
+//		{"use": "log", "exec": ["git", "log", "{{if .Flags.n}}-n{{.Flags.n}}{{end}}", "$@"],
+//		 "flags": [{"id": "n", "short": "n", "kind": "int"}]}
+//	]}
+//
+// Every element of exec is a text/template executed with .Flags, the values of flags by id
+// (the given value, else Env, else Default; a bool flag is "true" or empty, e.g. for '--no-flag'),
+// and .Args, the positional arguments. An element "$@" is replaced with all positional arguments,
+// elements that render to an empty string are omitted. The program uses the streams of the invocation.
+//
+// Errors are *SpecError pointing at the invalid part of the spec. Commands are not registered, see Cli.Register.
+func LoadSpec(r io.Reader, handlers Handlers) ([]*Command, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return loadSpec(data, handlers)
+}
+
+func loadSpec(data []byte, handlers Handlers) ([]*Command, error) {
+	idx := &specIndex{data: data, dec: json.NewDecoder(bytes.NewReader(data)), pos: make(map[string]int64)}
+	if err := idx.value("", reflect.TypeOf(specFile{})); err != nil {
+		return nil, idx.wrap(err)
+	}
+	var spec specFile
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, idx.wrap(err)
+	}
+	var cmds []*Command
+	taken := make(map[string]string)
+	for i := range spec.Commands {
+		path := fmt.Sprintf("commands[%d]", i)
+		cmd, err := buildSpecCommand(&spec.Commands[i], path, handlers, idx)
+		if err != nil {
+			return nil, err
+		}
+		if err := validateCommand(cmd, cmd.Use); err != nil {
+			return nil, idx.errorAt(path, err)
+		}
+		if err := takeNames(taken, cmd, ""); err != nil {
+			return nil, idx.errorAt(path, err)
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds, nil
+}
+
+// buildSpecCommand converts a spec command at path, checking what validateCommand does not
+func buildSpecCommand(s *specCommand, path string, handlers Handlers, idx *specIndex) (*Command, error) {
+	cmd := &Command{
+		Use:        s.Use,
+		Aliases:    s.Aliases,
+		Desc:       Description{Short: s.Desc, Long: s.LongDesc},
+		Example:    s.Example,
+		Deprecated: s.Deprecated,
+		Hidden:     s.Hidden,
+		Group:      s.Group,
+	}
+	if s.Use == "" || strings.ContainsFunc(s.Use, unicode.IsSpace) {
+		return nil, idx.errorf(path+".use", "%q is not a valid name", s.Use)
+	}
+	for i, f := range s.Flags {
+		fpath := fmt.Sprintf("%s.flags[%d]", path, i)
+		if !validKind(f.Kind) {
+			return nil, idx.errorf(fpath+".kind", "unknown kind %q", f.Kind)
+		}
+		id := f.ID
+		if id == "" {
+			id = f.Long
+		}
+		if id == "" {
+			id = f.Short
+		}
+		if id == "" {
+			return nil, idx.errorf(fpath, "flag has neither id, long nor short")
+		}
+		cmd.Flags = append(cmd.Flags, &CommandFlag{
+			Type:       id,
+			Long:       f.Long,
+			Short:      f.Short,
+			Desc:       Description{Short: f.Desc, Long: f.LongDesc},
+			Kind:       f.Kind,
+			Default:    f.Default,
+			Env:        f.Env,
+			Required:   f.Required,
+			Negatable:  f.Negatable,
+			Choices:    f.Choices,
+			Deprecated: f.Deprecated,
+			Hidden:     f.Hidden,
+		})
+	}
+	for i, g := range s.FlagGroups {
+		gpath := fmt.Sprintf("%s.flagGroups[%d]", path, i)
+		if !validGroupKind(g.Kind) {
+			return nil, idx.errorf(gpath+".kind", "unknown kind %q", g.Kind)
+		}
+		for j, id := range g.Flags {
+			if cmd.flagByType(id) == nil {
+				return nil, idx.errorf(fmt.Sprintf("%s.flags[%d]", gpath, j), "undeclared flag %q", id)
+			}
+		}
+		cmd.FlagGroups = append(cmd.FlagGroups, &FlagGroup{Kind: g.Kind, Flags: g.Flags})
+	}
+	for _, arg := range s.Args {
+		cmd.Args = append(cmd.Args, &CommandArg{
+			Name:     arg.Name,
+			Desc:     Description{Short: arg.Desc, Long: arg.LongDesc},
+			Required: arg.Required,
+			Variadic: arg.Variadic,
+			Choices:  arg.Choices,
+		})
+	}
+	switch {
+	case s.Handler != "" && s.Exec != nil:
+		return nil, idx.errorf(path, "both handler and exec are set")
+	case s.Handler != "":
+		handler, ok := handlers[s.Handler]
+		if !ok || handler == nil {
+			return nil, idx.errorf(path+".handler", "unknown handler %q", s.Handler)
+		}
+		cmd.RunE = handler
+	case s.Exec != nil:
+		run, err := specExec(s.Exec, cmd.Flags, path, idx)
+		if err != nil {
+			return nil, err
+		}
+		cmd.RunE = run
+	case len(s.Subcommands) == 0:
+		return nil, idx.errorf(path, "either handler, exec or subcommands must be set")
+	}
+	for i := range s.Subcommands {
+		sub, err := buildSpecCommand(&s.Subcommands[i], fmt.Sprintf("%s.subcommands[%d]", path, i), handlers, idx)
+		if err != nil {
+			return nil, err
+		}
+		cmd.Subcommands = append(cmd.Subcommands, sub)
+	}
+	return cmd, nil
+}
+
+func validKind(kind FlagKind) bool {
+	switch kind {
+	case "", KindBool, KindString, KindInt, KindUint, KindFloat, KindDuration, KindStrings:
+		return true
+	}
+	return false
+}
+
+// specExec returns a handler running the external program given by argv templates
+func specExec(exec []string, flags []*CommandFlag, path string, idx *specIndex) (func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error, error) {
+	if len(exec) == 0 {
+		return nil, idx.errorf(path+".exec", "exec must not be empty")
+	}
+	templates := make([]*template.Template, len(exec))
+	for i, arg := range exec {
+		tmpl, err := template.New(arg).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, idx.errorAt(fmt.Sprintf("%s.exec[%d]", path, i), fmt.Errorf("%w: %v", ErrInvalidSpec, err))
+		}
+		templates[i] = tmpl
+	}
+	declared := flags
+	return func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+		values := make(map[string]string)
+		for _, f := range declared {
+			values[f.Type] = f.Default
+			if v, ok := os.LookupEnv(f.Env); f.Env != "" && ok {
+				values[f.Type] = v
+			}
+			if parsed, ok := flags[f.Type]; ok {
+				values[f.Type] = parsed.Args
+				if parsed.Args == "" && (f.Kind == "" || f.Kind == KindBool) {
+					values[f.Type] = "true"
+				}
+			}
+			if b, err := strconv.ParseBool(values[f.Type]); err == nil && f.Kind == KindBool {
+				values[f.Type] = ""
+				if b {
+					values[f.Type] = "true"
+				}
+			}
+		}
+		data := struct {
+			Flags map[string]string
+			Args  []string
+		}{values, args}
+		var argv []string
+		for i, tmpl := range templates {
+			if exec[i] == "$@" {
+				argv = append(argv, args...)
+				continue
+			}
+			var b strings.Builder
+			if err := tmpl.Execute(&b, data); err != nil {
+				return err
+			}
+			if b.Len() > 0 {
+				argv = append(argv, b.String())
+			}
+		}
+		if len(argv) == 0 {
+			return errors.New("exec renders to an empty command")
+		}
+		streams := StreamsFrom(ctx)
+		run := osexec.CommandContext(ctx, argv[0], argv[1:]...)
+		run.Stdin, run.Stdout, run.Stderr = streams.Stdin, streams.Stdout, streams.Stderr
+		err := run.Run()
+		var exitErr *osexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return Exit(exitErr.ExitCode(), nil)
+		}
+		return err
+	}, nil
+}
+
+// specIndex records offsets of values in a spec by path while checking for unknown fields
+type specIndex struct {
+	data []byte
+	dec  *json.Decoder
+	pos  map[string]int64
+}
+
+// value reads a JSON value at path that is decoded into typ, nil if unknown
+func (x *specIndex) value(path string, typ reflect.Type) error {
+	x.pos[path] = x.offset()
+	tok, err := x.dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	switch delim {
+	case '{':
+		fields := jsonFields(typ)
+		for x.dec.More() {
+			keyPos := x.offset()
+			tok, err := x.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := tok.(string)
+			fieldType, known := fields[key]
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if fields != nil && !known {
+				x.pos[keyPath] = keyPos
+				return x.errorf(keyPath, "unknown field %q", key)
+			}
+			if err := x.value(keyPath, fieldType); err != nil {
+				return err
+			}
+		}
+	case '[':
+		var elem reflect.Type
+		if typ != nil && typ.Kind() == reflect.Slice {
+			elem = typ.Elem()
+		}
+		for i := 0; x.dec.More(); i++ {
+			if err := x.value(fmt.Sprintf("%s[%d]", path, i), elem); err != nil {
+				return err
+			}
+		}
+	}
+	_, err = x.dec.Token()
+	return err
+}
+
+// offset returns the offset of the next value, skipping separators the decoder has not consumed yet
+func (x *specIndex) offset() int64 {
+	off := x.dec.InputOffset()
+	for off < int64(len(x.data)) && strings.IndexByte(" \t\r\n:,", x.data[off]) >= 0 {
+		off++
+	}
+	return off
+}
+
+// jsonFields maps JSON names of struct fields to their types, nil if typ is not a struct
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if field.Anonymous && name == "" {
+			// fields of embedded structs are promoted as by encoding/json
+			for name, typ := range jsonFields(field.Type) {
+				fields[name] = typ
+			}
+			continue
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// errorf returns a SpecError at path
+func (x *specIndex) errorf(path, format string, args ...any) error {
+	return x.errorAt(path, fmt.Errorf("%w: "+format, append([]any{ErrInvalidSpec}, args...)...))
+}
+
+// errorAt returns a SpecError wrapping err at path
+func (x *specIndex) errorAt(path string, err error) error {
+	specErr := &SpecError{Path: path, Err: err}
+	if off, ok := x.pos[path]; ok {
+		specErr.Line, specErr.Column = x.lineColumn(off)
+	}
+	return specErr
+}
+
+// wrap adds the location of JSON syntax and type errors
+func (x *specIndex) wrap(err error) error {
+	var specErr *SpecError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &specErr):
+		return err
+	case errors.As(err, &syntaxErr):
+		specErr = &SpecError{Err: fmt.Errorf("%w: %v", ErrInvalidSpec, err)}
+		// the offset is after the invalid character
+		specErr.Line, specErr.Column = x.lineColumn(max(0, syntaxErr.Offset-1))
+	case errors.As(err, &typeErr):
+		// the error is reported after the value, so it belongs to the last value starting before it
+		path, start := "", int64(-1)
+		for p, off := range x.pos {
+			if off < typeErr.Offset && (off > start || off == start && len(p) > len(path)) {
+				path, start = p, off
+			}
+		}
+		return x.errorAt(path, fmt.Errorf("%w: expected %v, got %s", ErrInvalidSpec, typeErr.Type, typeErr.Value))
+	case err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF):
+		specErr = &SpecError{Err: fmt.Errorf("%w: unexpected end of input", ErrInvalidSpec)}
+		specErr.Line, specErr.Column = x.lineColumn(int64(len(x.data)))
+	default:
+		return err
+	}
+	return specErr
+}
+
+func (x *specIndex) lineColumn(off int64) (int, int) {
+	off = min(off, int64(len(x.data)))
+	before := x.data[:off]
+	line := bytes.Count(before, []byte("\n")) + 1
+	return line, int(off) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+}
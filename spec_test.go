@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSpec(t *testing.T) {
+	if _, err := exec.LookPath("echo"); err != nil {
+		t.Skip("echo is not available")
+	}
+	const spec = `{
+  "commands": [
+    {
+      "use": "greet",
+      "aliases": ["hi"],
+      "description": "Greets someone",
+      "group": "people",
+      "handler": "greet",
+      "flags": [
+        {"long": "name", "short": "n", "kind": "string", "required": true},
+        {"long": "formal", "kind": "bool"},
+        {"long": "casual", "kind": "bool"},
+        {"long": "polite", "kind": "bool", "hidden": true, "deprecated": {"replacement": "--formal"}}
+      ],
+      "flagGroups": [{"kind": "exclusive", "flags": ["formal", "casual"]}],
+      "args": [{"name": "extra", "variadic": true}]
+    },
+    {
+      "use": "tools",
+      "subcommands": [
+        {
+          "use": "say",
+          "exec": ["echo", "{{.Flags.prefix}}:", "{{if .Flags.loud}}LOUD{{end}}", "$@"],
+          "flags": [
+            {"id": "prefix", "long": "prefix", "kind": "string", "default": "said", "choices": ["said", "x"]},
+            {"id": "loud", "long": "loud", "kind": "bool", "negatable": true}
+          ]
+        }
+      ]
+    }
+  ]
+}`
+	var greeted string
+	handlers := Handlers{
+		"greet": func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			greeted = flags["name"].Args
+			return nil
+		},
+	}
+	cmds, err := LoadSpec(strings.NewReader(spec), handlers)
+	if err != nil {
+		t.Fatalf("LoadSpec() error = %v", err)
+	}
+	if cmds[0].Group != "people" {
+		t.Errorf("LoadSpec() group of greet = %q, want %q", cmds[0].Group, "people")
+	}
+	if f := cmds[0].GetFlag("polite"); f == nil || !f.Hidden || f.Deprecated == nil || f.Deprecated.Replacement != "--formal" {
+		t.Errorf("LoadSpec() flag polite = %+v, want hidden and deprecated", f)
+	}
+	c := NewCli()
+	if err := c.Register(cmds...); err != nil {
+		t.Fatalf("cli.Register() error = %v", err)
+	}
+	if err := c.OneCmd("hi --name=bob"); err != nil || greeted != "bob" {
+		t.Errorf("greet = %q, %v, want %q", greeted, err, "bob")
+	}
+	if err := c.OneCmd("greet"); !errors.Is(err, ErrUsage) {
+		t.Errorf("greet without required flag error = %v, want %v", err, ErrUsage)
+	}
+	if err := c.OneCmd("greet --name=bob --formal --casual"); !errors.Is(err, ErrUsage) {
+		t.Errorf("greet with exclusive flags error = %v, want %v", err, ErrUsage)
+	}
+	if err := c.OneCmd("tools say --prefix=y a"); !errors.Is(err, ErrInvalidFlagValue) {
+		t.Errorf("say with invalid choice error = %v, want %v", err, ErrInvalidFlagValue)
+	}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{line: "tools say a b", want: "said: a b\n"},
+		{line: "tools say --loud --prefix=x 'a b'", want: "x: LOUD a b\n"},
+		{line: "tools say --no-loud a", want: "said: a\n"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+		if err := c.RunLine(ctx, tt.line); err != nil {
+			t.Fatalf("cli.RunLine(%q) error = %v", tt.line, err)
+		}
+		if out.String() != tt.want {
+			t.Errorf("cli.RunLine(%q) output = %q, want %q", tt.line, out.String(), tt.want)
+		}
+	}
+}
+
+func TestLoadSpec_errors(t *testing.T) {
+	handlers := Handlers{"ok": func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error { return nil }}
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "syntax",
+			spec: "{\"commands\": [\n  {\"use\": \"a\",}\n]}",
+			want: "2:14: invalid spec: invalid character ',' looking for beginning of value",
+		},
+		{
+			name: "unknown field",
+			spec: "{\"commands\": [\n  {\"use\": \"a\", \"handler\": \"ok\", \"flag\": []}\n]}",
+			want: "2:33: commands[0].flag: invalid spec: unknown field \"flag\"",
+		},
+		{
+			name: "wrong type",
+			spec: "{\"commands\": [\n  {\"use\": \"a\", \"handler\": \"ok\",\n   \"flags\": [{\"long\": \"x\", \"required\": \"yes\"}]}\n]}",
+			want: "3:40: commands[0].flags[0].required: invalid spec: expected bool, got string",
+		},
+		{
+			name: "unknown handler",
+			spec: "{\"commands\": [\n  {\"use\": \"a\", \"handler\": \"missing\"}\n]}",
+			want: "2:27: commands[0].handler: invalid spec: unknown handler \"missing\"",
+		},
+		{
+			name: "handler and exec",
+			spec: "{\"commands\": [{\"use\": \"a\", \"handler\": \"ok\", \"exec\": [\"true\"]}]}",
+			want: "1:15: commands[0]: invalid spec: both handler and exec are set",
+		},
+		{
+			name: "nothing to run",
+			spec: "{\"commands\": [{\"use\": \"a\", \"subcommands\": [{\"use\": \"b\"}]}]}",
+			want: "1:44: commands[0].subcommands[0]: invalid spec: either handler, exec or subcommands must be set",
+		},
+		{
+			name: "invalid template",
+			spec: "{\"commands\": [{\"use\": \"a\", \"exec\": [\"echo\", \"{{.Flags\"]}]}",
+			want: "1:45: commands[0].exec[1]: invalid spec: template: {{.Flags:1: unclosed action",
+		},
+		{
+			name: "unknown kind",
+			spec: "{\"commands\": [{\"use\": \"a\", \"handler\": \"ok\", \"flags\": [{\"long\": \"x\", \"kind\": \"number\"}]}]}",
+			want: "1:77: commands[0].flags[0].kind: invalid spec: unknown kind \"number\"",
+		},
+		{
+			name: "unknown group kind",
+			spec: "{\"commands\": [{\"use\": \"a\", \"handler\": \"ok\", \"flagGroups\": [{\"kind\": \"all\", \"flags\": []}]}]}",
+			want: "1:69: commands[0].flagGroups[0].kind: invalid spec: unknown kind \"all\"",
+		},
+		{
+			name: "undeclared group flag",
+			spec: "{\"commands\": [{\"use\": \"a\", \"handler\": \"ok\", \"flagGroups\": [{\"kind\": \"exclusive\", \"flags\": [\"x\"]}]}]}",
+			want: "1:92: commands[0].flagGroups[0].flags[0]: invalid spec: undeclared flag \"x\"",
+		},
+		{
+			name: "conflicting flags",
+			spec: "{\"commands\": [{\"use\": \"a\", \"handler\": \"ok\", \"flags\": [{\"long\": \"x\"}, {\"id\": \"y\", \"long\": \"x\"}]}]}",
+			want: "1:15: commands[0]: conflicting flag: a: --x is declared twice",
+		},
+		{
+			name: "duplicate command",
+			spec: "{\"commands\": [{\"use\": \"a\", \"handler\": \"ok\"}, {\"use\": \"b\", \"aliases\": [\"a\"], \"handler\": \"ok\"}]}",
+			want: "1:46: commands[1]: duplicate command: a is already used by a",
+		},
+		{
+			name: "truncated",
+			spec: "{\"commands\": [",
+			want: "1:14: invalid spec: unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSpec(strings.NewReader(tt.spec), handlers)
+			var specErr *SpecError
+			if !errors.As(err, &specErr) {
+				t.Fatalf("LoadSpec() error = %v, want a *SpecError", err)
+			}
+			if err.Error() != tt.want {
+				t.Errorf("LoadSpec() error = %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSpecFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "commands.json")
+	if err := os.WriteFile(path, []byte(`{"commands": [{"use": "a"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadSpecFile(path, nil)
+	if err == nil || !strings.HasPrefix(err.Error(), path+":1:15: commands[0]: ") || !errors.Is(err, ErrInvalidSpec) {
+		t.Errorf("LoadSpecFile() error = %v, want an ErrInvalidSpec at %s:1:15", err, path)
+	}
+}
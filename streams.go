@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+)
+
+// Streams are the standard streams of a command invocation
+type Streams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+type streamsKey struct{}
+
+// ContextWithStreams returns a copy of ctx whose commands use the given streams.
+// Nil fields are taken from the streams already in ctx.
+func ContextWithStreams(ctx context.Context, s Streams) context.Context {
+	return context.WithValue(ctx, streamsKey{}, s.orElse(StreamsFrom(ctx)))
+}
+
+// StreamsFrom returns the streams of the invocation running with ctx, os.Stdin, os.Stdout and os.Stderr by default.
+// Handlers given ctx, such as RunE and hooks, should use them instead of the os streams.
+func StreamsFrom(ctx context.Context) Streams {
+	s, _ := ctx.Value(streamsKey{}).(Streams)
+	return s.orElse(Streams{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr})
+}
+
+// orElse fills nil fields of s from def
+func (s Streams) orElse(def Streams) Streams {
+	if s.Stdin == nil {
+		s.Stdin = def.Stdin
+	}
+	if s.Stdout == nil {
+		s.Stdout = def.Stdout
+	}
+	if s.Stderr == nil {
+		s.Stderr = def.Stderr
+	}
+	return s
+}
+
+// Sets the stdin of commands run without streams in their context, os.Stdin by default
+func WithStdin(r io.Reader) Option {
+	return func(c *cli) {
+		c.streams.Stdin = r
+	}
+}
+
+// Sets the stdout of commands run without streams in their context, os.Stdout by default
+func WithStdout(w io.Writer) Option {
+	return func(c *cli) {
+		c.streams.Stdout = w
+	}
+}
+
+// Sets the stderr of commands run without streams in their context, os.Stderr by default
+func WithStderr(w io.Writer) Option {
+	return func(c *cli) {
+		c.streams.Stderr = w
+	}
+}
+
+// withStreams returns ctx with the streams set by WithStdin, WithStdout and WithStderr, unless ctx has streams
+func (c *cli) withStreams(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(streamsKey{}).(Streams); ok {
+		return ctx
+	}
+	return ContextWithStreams(ctx, c.streams)
+}
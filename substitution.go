@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DefaultMaxSubstitutionDepth is the default limit of nested command substitutions
+const DefaultMaxSubstitutionDepth = 16
+
+// ErrSubstitutionDepth is returned when command substitutions are nested deeper than the limit
+var ErrSubstitutionDepth = errors.New("command substitution nested too deeply")
+
+// Sets the limit of nested command substitutions, DefaultMaxSubstitutionDepth by default
+func WithMaxSubstitutionDepth(depth int) Option {
+	return func(c *cli) {
+		c.maxSubstDepth = depth
+	}
+}
+
+type substDepthKey struct{}
+
+// substitute runs the command line of $(line) through the Cli and returns its trimmed stdout.
+// The nested commands read an empty stdin and share stderr with the outer command.
+func (c *cli) substitute(ctx context.Context, line string) (string, error) {
+	depth, _ := ctx.Value(substDepthKey{}).(int)
+	if depth >= c.maxSubstDepth {
+		return "", fmt.Errorf("$(%s): %w (limit %d)", line, ErrSubstitutionDepth, c.maxSubstDepth)
+	}
+	var out strings.Builder
+	ctx = context.WithValue(ctx, substDepthKey{}, depth+1)
+	ctx = ContextWithStreams(ctx, Streams{Stdin: strings.NewReader(""), Stdout: &out})
+	if err := c.runLine(ctx, line); err != nil {
+		return "", fmt.Errorf("$(%s): %w", line, err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_cli_substitution(t *testing.T) {
+	var gotArgs []string
+	errFailed := errors.New("failed")
+	newCli := func(opts ...Option) Cli {
+		c := NewCli(opts...)
+		c.AddCmd(
+			&Command{
+				Use: "print",
+				RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+					_, err := fmt.Fprintf(StreamsFrom(ctx).Stdout, "  %s\n\n", strings.Join(args, " "))
+					return err
+				},
+			},
+			&Command{
+				Use: "record",
+				Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+					gotArgs = args
+				},
+			},
+			&Command{
+				Use: "fail",
+				RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+					return errFailed
+				},
+			},
+		)
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "single token",
+			input: "record $(print a b) x$(print c)y",
+			want: []string{"a b", "xcy"},
+		},
+		{
+			name: "in double quotes only",
+			input: "record \"[$(print a)]\" '$(print a)'",
+			want: []string{"[a]", "$(print a)"},
+		},
+		{
+			name: "nested with quotes and operators",
+			input: "record $(fail || print $(print 'x)') | print z) $(fail || print $(print 'x)'))",
+			want: []string{"z", "x)"},
+		},
+		{
+			name: "variables",
+			input: "set v 1; record $(print $v)",
+			want: []string{"1"},
+		},
+		{
+			name: "error propagation",
+			input: "record $(fail)",
+			wantErr: errFailed,
+		},
+		{
+			name: "depth limit",
+			opts: []Option{WithMaxSubstitutionDepth(1)},
+			input: "record $(print $(print x))",
+			wantErr: ErrSubstitutionDepth,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArgs = nil
+			err := newCli(tt.opts...).RunLine(context.Background(), tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.RunLine() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.want) {
+				t.Errorf("args = %q, want %q", gotArgs, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseList("record $(print"); err == nil {
+		t.Errorf("parseList() must fail on unclosed substitution")
+	}
+}
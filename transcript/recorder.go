@@ -0,0 +1,54 @@
+package transcript
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/AlexandrShapkin/cli"
+	"github.com/AlexandrShapkin/cli/internal/syncbuf"
+)
+
+// Recorder is a Cli writing a transcript entry for every command line run with OneCmd, RunLine or Interact.
+// Output of the command lines is captured for the transcript and also written to the streams of their context.
+// Other methods are passed to the wrapped Cli unchanged.
+type Recorder struct {
+	cli.Cli
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewRecorder returns a Recorder running command lines with c and writing the transcript to w
+func NewRecorder(c cli.Cli, w io.Writer) *Recorder {
+	return &Recorder{Cli: c, w: w}
+}
+
+// OneCmd runs a command line and records it
+func (r *Recorder) OneCmd(input string) error {
+	return r.RunLine(context.Background(), input)
+}
+
+// RunLine runs a command line and records it.
+// An error writing the transcript is returned if the command line succeeded.
+func (r *Recorder) RunLine(ctx context.Context, line string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	streams := cli.StreamsFrom(ctx)
+	var stdout, stderr syncbuf.Builder
+	ctx = cli.ContextWithStreams(ctx, cli.Streams{
+		Stdout: io.MultiWriter(streams.Stdout, &stdout),
+		Stderr: io.MultiWriter(streams.Stderr, &stderr),
+	})
+	err := r.Cli.RunLine(ctx, line)
+	if werr := Write(r.w, newEntry(line, stdout.String(), stderr.String(), err)); werr != nil && err == nil {
+		err = fmt.Errorf("writing transcript: %w", werr)
+	}
+	return err
+}
+
+// Interact runs and records command lines read from the stdin of ctx until end of input like Cli.Interact
+func (r *Recorder) Interact(ctx context.Context, prompt string) error {
+	return r.Cli.InteractWith(ctx, prompt, r.RunLine)
+}
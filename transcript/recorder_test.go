@@ -0,0 +1,75 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+func testCli(version string) cli.Cli {
+	c := cli.NewCli()
+	c.AddCmd(
+		&cli.Command{
+			Use: "version",
+			Handle: func(inv *cli.Invocation) error {
+				fmt.Fprintln(inv.Stdout, version)
+				return nil
+			},
+		},
+		&cli.Command{
+			Use: "warn",
+			Handle: func(inv *cli.Invocation) error {
+				fmt.Fprint(inv.Stderr, strings.Join(inv.Args, " "))
+				return nil
+			},
+		},
+		&cli.Command{
+			Use: "fail",
+			Handle: func(inv *cli.Invocation) error {
+				return cli.Exit(3, errors.New("failed"))
+			},
+		},
+	)
+	return c
+}
+
+func TestRecorder(t *testing.T) {
+	var w, stdout, stderr strings.Builder
+	r := NewRecorder(testCli("1.0"), &w)
+	ctx := cli.ContextWithStreams(context.Background(), cli.Streams{
+		Stdin:  strings.NewReader("version\n\n  \nwarn low disk\nfail && version\nnope"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	if err := r.Interact(ctx, "> "); err != nil {
+		t.Fatalf("Interact() error = %v", err)
+	}
+	if err := r.RunLine(ctx, "version | version"); err != nil {
+		t.Fatalf("RunLine() error = %v", err)
+	}
+	want := `$ version
+| 1.0
+$ warn low disk
+2| low disk
+\ no newline
+$ fail && version
+! exit 3: failed
+$ nope
+! exit 2: command nope not found
+$ version | version
+| 1.0
+`
+	if w.String() != want {
+		t.Errorf("transcript =\n%s\nwant:\n%s", w.String(), want)
+	}
+	if want := "1.0\n1.0\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+	if want := "low diskfailed\ncommand nope not found\n"; stderr.String() != want {
+		t.Errorf("stderr = %q, want %q", stderr.String(), want)
+	}
+}
@@ -0,0 +1,79 @@
+package transcript
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/AlexandrShapkin/cli"
+	"github.com/AlexandrShapkin/cli/internal/syncbuf"
+)
+
+// Diff is a difference between a recorded entry and its replay
+type Diff struct {
+	Index int    // index of the entry in the transcript
+	Line  string // command line of the entry
+	Field string // "stdout", "stderr", "error" or "exit code"
+	Want  string // recorded value
+	Got   string // value of the replay
+}
+
+func (d Diff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "entry %d: $ %s\n%s differs:\n", d.Index+1, d.Line, d.Field)
+	writeDiffLines(&b, "-", d.Want)
+	writeDiffLines(&b, "+", d.Got)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func writeDiffLines(b *strings.Builder, prefix, s string) {
+	text, newline := strings.CutSuffix(s, "\n")
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(prefix + " " + line + "\n")
+	}
+	if s != "" && !newline {
+		b.WriteString(noNewlineMarker + "\n")
+	}
+}
+
+// Replay runs the command lines of entries with c in order and returns differences with the recorded outcomes.
+// Command lines are run with empty stdin, output is captured and not written anywhere else.
+func Replay(ctx context.Context, c cli.Cli, entries []Entry) []Diff {
+	var diffs []Diff
+	for i, want := range entries {
+		var stdout, stderr syncbuf.Builder
+		lineCtx := cli.ContextWithStreams(ctx, cli.Streams{
+			Stdin:  strings.NewReader(""),
+			Stdout: &stdout,
+			Stderr: &stderr,
+		})
+		got := newEntry(want.Line, "", "", c.RunLine(lineCtx, want.Line))
+		got.Stdout, got.Stderr = stdout.String(), stderr.String()
+		add := func(field, recorded, replayed string) {
+			if recorded != replayed {
+				diffs = append(diffs, Diff{Index: i, Line: want.Line, Field: field, Want: recorded, Got: replayed})
+			}
+		}
+		add("stdout", want.Stdout, got.Stdout)
+		add("stderr", want.Stderr, got.Stderr)
+		add("error", want.Err, got.Err)
+		add("exit code", strconv.Itoa(want.ExitCode), strconv.Itoa(got.ExitCode))
+	}
+	return diffs
+}
+
+// ReplayFile reads a transcript from a file and replays it with c, see Replay
+func ReplayFile(ctx context.Context, c cli.Cli, path string) ([]Diff, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	entries, err := Read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return Replay(ctx, c, entries), nil
+}
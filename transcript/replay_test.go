@@ -0,0 +1,66 @@
+package transcript
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+func TestReplay(t *testing.T) {
+	var w strings.Builder
+	r := NewRecorder(testCli("1.0"), &w)
+	ctx := context.Background()
+	var discard strings.Builder
+	lineCtx := cli.ContextWithStreams(ctx, cli.Streams{Stdout: &discard, Stderr: &discard})
+	for _, line := range []string{"version", "warn a", "fail", "nope"} {
+		r.RunLine(lineCtx, line)
+	}
+	entries, err := Read(strings.NewReader(w.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := Replay(ctx, testCli("1.0"), entries); len(diffs) != 0 {
+		t.Errorf("Replay() of the same Cli = %v, want no diffs", diffs)
+	}
+
+	entries[2].ExitCode = 4
+	want := []Diff{
+		{Index: 0, Line: "version", Field: "stdout", Want: "1.0\n", Got: "2.0\n"},
+		{Index: 2, Line: "fail", Field: "exit code", Want: "4", Got: "3"},
+	}
+	got := Replay(ctx, testCli("2.0"), entries)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Replay() = %#v, want %#v", got, want)
+	}
+	wantString := "entry 1: $ version\nstdout differs:\n- 1.0\n+ 2.0"
+	if got[0].String() != wantString {
+		t.Errorf("Diff.String() = %q, want %q", got[0].String(), wantString)
+	}
+}
+
+func TestReplayFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.txt")
+	if err := os.WriteFile(path, []byte("$ warn x\n2| y\n\\ no newline\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	diffs, err := ReplayFile(context.Background(), testCli("1.0"), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "entry 1: $ warn x\nstderr differs:\n- y\n\\ no newline\n+ x\n\\ no newline"
+	if len(diffs) != 1 || diffs[0].String() != want {
+		t.Errorf("ReplayFile() = %q, want one diff %q", diffs, want)
+	}
+
+	if err := os.WriteFile(path, []byte("garbage\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReplayFile(context.Background(), testCli("1.0"), path); err == nil {
+		t.Errorf("ReplayFile() of an invalid transcript expected an error")
+	}
+}
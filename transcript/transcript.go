@@ -0,0 +1,211 @@
+// Package transcript records command lines run by a cli.Cli with their output and errors,
+// and replays recorded transcripts against a Cli reporting differences.
+//
+// A transcript is a text file of entries, each starting with the command line:
+//
+//	# comments and blank lines are ignored
+//	$ db migrate --to=3
+//	| migrated to 3
+//	2| warning: no backup
+//	$ drop
+//	! exit 2: command drop not found
+//
+// Lines starting with '| ' and '2| ' hold stdout and stderr of the command line,
+// '! exit <code>: ' holds its error and exit code, following '! ' lines continue the error message.
+// A '\ no newline' line marks that the preceding output line does not end with a newline.
+package transcript
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/AlexandrShapkin/cli"
+)
+
+// ErrInvalid is wrapped by errors of Read for malformed transcripts
+var ErrInvalid = errors.New("invalid transcript")
+
+const (
+	linePrefix      = "$ "
+	stdoutPrefix    = "|"
+	stderrPrefix    = "2|"
+	errPrefix       = "!"
+	noNewlineMarker = `\ no newline`
+)
+
+// Entry is a command line with its outcome
+type Entry struct {
+	Line     string
+	Stdout   string
+	Stderr   string
+	Err      string // message of the error, empty if the line succeeded
+	ExitCode int    // cli.ExitCode of the error
+}
+
+func newEntry(line, stdout, stderr string, err error) Entry {
+	e := Entry{Line: line, Stdout: stdout, Stderr: stderr, ExitCode: cli.ExitCode(err)}
+	if err != nil {
+		e.Err = err.Error()
+	}
+	return e
+}
+
+// failed reports whether the command line returned an error
+func (e Entry) failed() bool {
+	return e.Err != "" || e.ExitCode != cli.ExitOK
+}
+
+// Write writes entries to w in the transcript format
+func Write(w io.Writer, entries ...Entry) error {
+	b := &strings.Builder{}
+	for _, e := range entries {
+		b.WriteString(linePrefix + e.Line + "\n")
+		writeLines(b, stdoutPrefix, e.Stdout)
+		writeLines(b, stderrPrefix, e.Stderr)
+		if e.failed() {
+			for i, line := range strings.Split(e.Err, "\n") {
+				if i == 0 {
+					line = strings.TrimSuffix(fmt.Sprintf("exit %d: %s", e.ExitCode, line), " ")
+				}
+				writeLine(b, errPrefix, line)
+			}
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeLines writes s line by line with prefix, marking a missing final newline
+func writeLines(b *strings.Builder, prefix, s string) {
+	if s == "" {
+		return
+	}
+	text, newline := strings.CutSuffix(s, "\n")
+	for _, line := range strings.Split(text, "\n") {
+		writeLine(b, prefix, line)
+	}
+	if !newline {
+		b.WriteString(noNewlineMarker + "\n")
+	}
+}
+
+func writeLine(b *strings.Builder, prefix, line string) {
+	if line == "" {
+		b.WriteString(prefix + "\n")
+		return
+	}
+	b.WriteString(prefix + " " + line + "\n")
+}
+
+// Read parses a transcript written by Write or by hand
+func Read(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	var (
+		out   *strings.Builder // output the last line was appended to
+		outs  [2]strings.Builder
+		errs  []string
+		entry *Entry
+	)
+	flush := func() {
+		if entry == nil {
+			return
+		}
+		entry.Stdout, entry.Stderr = outs[0].String(), outs[1].String()
+		entry.Err = strings.Join(errs, "\n")
+		entries = append(entries, *entry)
+		outs[0].Reset()
+		outs[1].Reset()
+		out, errs, entry = nil, nil, nil
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		invalid := func(format string, args ...any) error {
+			return fmt.Errorf("%w: line %d: %s", ErrInvalid, n, fmt.Sprintf(format, args...))
+		}
+		if strings.HasPrefix(line, linePrefix) {
+			flush()
+			entry = &Entry{Line: strings.TrimPrefix(line, linePrefix)}
+			continue
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if entry == nil {
+			return nil, invalid("expected a command line starting with %q", linePrefix)
+		}
+		if line == noNewlineMarker {
+			if out == nil || !strings.HasSuffix(out.String(), "\n") {
+				return nil, invalid("%q must follow an output line", noNewlineMarker)
+			}
+			s := strings.TrimSuffix(out.String(), "\n")
+			out.Reset()
+			out.WriteString(s)
+			continue
+		}
+		stream := -1
+		text, ok := cutPrefix(line, stderrPrefix)
+		if ok {
+			stream = 1
+		} else if text, ok = cutPrefix(line, stdoutPrefix); ok {
+			stream = 0
+		}
+		if stream >= 0 {
+			out = &outs[stream]
+			if s := out.String(); s != "" && !strings.HasSuffix(s, "\n") {
+				return nil, invalid("output continues after %q", noNewlineMarker)
+			}
+			out.WriteString(text + "\n")
+			continue
+		}
+		if text, ok := cutPrefix(line, errPrefix); ok {
+			out = nil
+			if errs == nil {
+				code, msg, ok := parseExit(text)
+				if !ok {
+					return nil, invalid("expected '! exit <code>: <error>'")
+				}
+				entry.ExitCode = code
+				text = msg
+			}
+			errs = append(errs, text)
+			continue
+		}
+		return nil, invalid("unexpected %q", line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return entries, nil
+}
+
+// cutPrefix cuts prefix optionally followed by a space from a line
+func cutPrefix(line, prefix string) (string, bool) {
+	if line == prefix {
+		return "", true
+	}
+	return strings.CutPrefix(line, prefix+" ")
+}
+
+// parseExit parses 'exit <code>: <error>'
+func parseExit(s string) (int, string, bool) {
+	s, ok := strings.CutPrefix(s, "exit ")
+	if !ok {
+		return 0, "", false
+	}
+	code, msg, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, "", false
+	}
+	n, err := strconv.Atoi(code)
+	if err != nil {
+		return 0, "", false
+	}
+	return n, strings.TrimPrefix(msg, " "), true
+}
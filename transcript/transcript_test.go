@@ -0,0 +1,109 @@
+package transcript
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	err := Write(&b,
+		Entry{Line: "db migrate --to=3", Stdout: "migrated\n\nto 3\n", Stderr: "warning"},
+		Entry{Line: "db drop", Err: "unknown command \"drop\"\nsee help", ExitCode: 2},
+		Entry{Line: "exit", Err: "", ExitCode: 3},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `$ db migrate --to=3
+| migrated
+|
+| to 3
+2| warning
+\ no newline
+$ db drop
+! exit 2: unknown command "drop"
+! see help
+$ exit
+! exit 3:
+`
+	if b.String() != want {
+		t.Errorf("Write() =\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Entry
+		wantErr bool
+	}{
+		{
+			name: "entries",
+			input: `# session
+
+$ db migrate --to=3
+| migrated
+|
+| to 3
+2| warning
+\ no newline
+| more
+
+$ db drop
+! exit 2: unknown command "drop"
+! see help
+$ ls
+| a
+\ no newline
+`,
+			want: []Entry{
+				{Line: "db migrate --to=3", Stdout: "migrated\n\nto 3\nmore\n", Stderr: "warning"},
+				{Line: "db drop", Err: "unknown command \"drop\"\nsee help", ExitCode: 2},
+				{Line: "ls", Stdout: "a"},
+			},
+		},
+		{name: "empty", input: "# nothing\n"},
+		{name: "output before a line", input: "| out\n$ ls\n", wantErr: true},
+		{name: "marker without output", input: "$ ls\n\\ no newline\n", wantErr: true},
+		{name: "output after marker", input: "$ ls\n| a\n\\ no newline\n| b\n", wantErr: true},
+		{name: "error without exit code", input: "$ ls\n! failed\n", wantErr: true},
+		{name: "unknown line", input: "$ ls\nout\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Read(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Read() error = %v, want ErrInvalid", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	entries := []Entry{
+		{Line: "a", Stdout: "x\n y \n", Stderr: "\n"},
+		{Line: "b", Stdout: "no newline", Err: "failed", ExitCode: 1},
+		{Line: "c | d", Stderr: "e1\ne2"},
+	}
+	var b strings.Builder
+	if err := Write(&b, entries...); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("Read(Write()) = %#v, want %#v", got, entries)
+	}
+}
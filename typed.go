@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// NewTypedCommand creates a command whose flags are derived from the tagged options struct Opts, see Binder.
+//
+// Opts may be a struct or a pointer to a struct. For every invocation a fresh instance is filled
+// from flags, environment and defaults by Command.Bind and passed to fn; conversion errors are returned
+// before init and pre-run hooks run. NewTypedCommand panics if Opts can not be bound.
+func NewTypedCommand[Opts any](use string, fn func(ctx context.Context, opts Opts, args []string) error) *Command {
+	var zero Opts
+	b, err := NewBinder(zero)
+	if err != nil {
+		panic(fmt.Sprintf("cli: NewTypedCommand(%q): %v", use, err))
+	}
+	isPointer := reflect.TypeOf(zero).Kind() == reflect.Pointer
+	return &Command{
+		Use:   use,
+		Flags: b.Flags(),
+		Bind: func(flags map[string]*ParsedCommandFlags) (any, error) {
+			opts, err := b.New(flags)
+			if err != nil {
+				return nil, err
+			}
+			if !isPointer {
+				opts = reflect.ValueOf(opts).Elem().Interface()
+			}
+			return opts, nil
+		},
+		Handle: func(inv *Invocation) error {
+			return fn(inv.Context(), inv.Bound.(Opts), inv.Args)
+		},
+	}
+}
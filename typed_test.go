@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type typedTestOpts struct {
+	Name  string `cli:"name,n" default:"guest"`
+	Count int    `cli:"count,c"`
+}
+
+func Test_NewTypedCommand(t *testing.T) {
+	var got typedTestOpts
+	var gotArgs []string
+	invoked := false
+	cli := NewCli()
+	cli.AddCmd(
+		NewTypedCommand("greet", func(ctx context.Context, opts typedTestOpts, args []string) error {
+			invoked = true
+			got, gotArgs = opts, args
+			return nil
+		}),
+		NewTypedCommand("fail", func(ctx context.Context, opts *typedTestOpts, args []string) error {
+			return errors.New("failed")
+		}),
+	)
+
+	tests := []struct {
+		name     string
+		input    string
+		want     typedTestOpts
+		wantArgs []string
+		wantRun  bool
+		wantErr  error
+	}{
+		{
+			name: "defaults",
+			input: "greet",
+			want: typedTestOpts{Name: "guest"},
+			wantRun: true,
+		},
+		{
+			name: "typed flags and args",
+			input: "greet --name=bob --count=3 arg",
+			want: typedTestOpts{Name: "bob", Count: 3},
+			wantArgs: []string{"arg"},
+			wantRun: true,
+		},
+		{
+			name: "conversion error stops before handler",
+			input: "greet --count=three",
+			wantErr: ErrInvalidFlagValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotArgs, invoked = typedTestOpts{}, nil, false
+			if err := cli.OneCmd(tt.input); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.OneCmd() error = %v, want %v", err, tt.wantErr)
+			}
+			if invoked != tt.wantRun {
+				t.Fatalf("handler invoked = %v, want %v", invoked, tt.wantRun)
+			}
+			if got != tt.want || len(gotArgs) != len(tt.wantArgs) || (len(gotArgs) > 0 && !reflect.DeepEqual(gotArgs, tt.wantArgs)) {
+				t.Errorf("handler got %+v %v, want %+v %v", got, gotArgs, tt.want, tt.wantArgs)
+			}
+		})
+	}
+
+	if err := cli.OneCmd("fail"); err == nil || err.Error() != "failed" {
+		t.Errorf("cli.OneCmd() error = %v, want handler error", err)
+	}
+}
+
+func Test_NewTypedCommand_errorBeforeHooks(t *testing.T) {
+	var ran []string
+	c := NewCli()
+	c.OnInit(func(ctx context.Context) error {
+		ran = append(ran, "init")
+		return nil
+	})
+	cmd := NewTypedCommand("greet", func(ctx context.Context, opts typedTestOpts, args []string) error {
+		ran = append(ran, "greet")
+		return nil
+	})
+	cmd.PersistentPreRun = func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+		ran = append(ran, "pre")
+		return nil
+	}
+	c.AddCmd(cmd)
+	if err := c.OneCmd("greet --count=abc"); !errors.Is(err, ErrInvalidFlagValue) {
+		t.Fatalf("cli.OneCmd() error = %v, want %v", err, ErrInvalidFlagValue)
+	}
+	if len(ran) != 0 {
+		t.Errorf("ran = %q before the conversion error, want nothing", ran)
+	}
+	if err := c.OneCmd("greet --count=1"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"init", "pre", "greet"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran = %q, want %q", ran, want)
+	}
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Validator checks a value of a flag or a positional argument, see CommandFlag.Validate and CommandArg.Validate
+type Validator func(value string) error
+
+// MatchRegexp returns a Validator accepting values fully matching expr. It panics if expr is invalid.
+func MatchRegexp(expr string) Validator {
+	re := regexp.MustCompile(`^(?:` + expr + `)$`)
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q does not match %s", value, expr)
+		}
+		return nil
+	}
+}
+
+// InRange returns a Validator accepting numbers from min to max inclusive
+func InRange(min, max float64) Validator {
+	return func(value string) error {
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+		if n < min || n > max {
+			return fmt.Errorf("%s is out of range [%s, %s]", value, formatFloat(min), formatFloat(max))
+		}
+		return nil
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// FileExists returns a Validator accepting paths of existing files or directories
+func FileExists() Validator {
+	return func(value string) error {
+		if _, err := os.Stat(value); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("%s does not exist", value)
+			}
+			return err
+		}
+		return nil
+	}
+}
+
+// checkValue checks value against choices and validate, both optional
+func checkValue(value string, choices []string, validate Validator) error {
+	if len(choices) > 0 && !slices.Contains(choices, value) {
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(choices, ", "))
+	}
+	if validate != nil {
+		return validate(value)
+	}
+	return nil
+}
+
+// checkValues validates values of given flags, flags set by Env and positional arguments
+// against their Choices and Validate
+func checkValues(cmd *Command, flags map[string]*ParsedCommandFlags, args []string) error {
+	for _, f := range cmd.Flags {
+		if len(f.Choices) == 0 && f.Validate == nil {
+			continue
+		}
+		value, ok := "", false
+		if parsed, given := flags[f.Type]; given {
+			value, ok = parsed.Args, parsed.Args != "" || (f.Kind != "" && f.Kind != KindBool)
+		} else if f.Env != "" {
+			value, ok = os.LookupEnv(f.Env)
+		}
+		if !ok {
+			continue
+		}
+		values := []string{value}
+		if f.Kind == KindStrings {
+			values = strings.Split(value, ",")
+		}
+		for _, v := range values {
+			if err := checkValue(v, f.Choices, f.Validate); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidFlagValue, f.display(), err)
+			}
+		}
+	}
+	for i, value := range args {
+		if len(cmd.Args) == 0 {
+			break
+		}
+		arg := cmd.Args[min(i, len(cmd.Args)-1)]
+		if i >= len(cmd.Args) && !arg.Variadic {
+			break
+		}
+		if err := checkValue(value, arg.Choices, arg.Validate); err != nil {
+			return usagef("invalid argument %s: %v", arg.usage(), err)
+		}
+	}
+	return nil
+}
+
+// choicesHelp returns choices as they are shown in help, e.g. 'one of json|table', or an empty string
+func choicesHelp(choices []string) string {
+	if len(choices) == 0 {
+		return ""
+	}
+	return "one of " + strings.Join(choices, "|")
+}
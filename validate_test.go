@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		validate Validator
+		value    string
+		wantErr  string
+	}{
+		{name: "regexp", validate: MatchRegexp(`v\d+`), value: "v12"},
+		{name: "regexp partial", validate: MatchRegexp(`v\d+`), value: "xv12", wantErr: `"xv12" does not match v\d+`},
+		{name: "range", validate: InRange(1, 10), value: "10"},
+		{name: "out of range", validate: InRange(1, 10), value: "0.5", wantErr: "0.5 is out of range [1, 10]"},
+		{name: "not a number", validate: InRange(1, 10), value: "x", wantErr: `"x" is not a number`},
+		{name: "file exists", validate: FileExists(), value: dir},
+		{name: "file does not exist", validate: FileExists(), value: filepath.Join(dir, "nope"), wantErr: filepath.Join(dir, "nope") + " does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.validate(tt.value); errText(err) != tt.wantErr {
+				t.Errorf("Validator(%q) error = %v, want %q", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_cli_checkValues(t *testing.T) {
+	c := NewCli()
+	c.AddCmd(&Command{
+		Use: "deploy",
+		Flags: []*CommandFlag{
+			{Type: "format", Long: "format", Kind: KindString, Choices: []string{"json", "table"}, Desc: Description{Short: "Output format"}},
+			{Type: "tags", Long: "tags", Kind: KindStrings, Choices: []string{"a", "b"}},
+			{Type: "replicas", Long: "replicas", Kind: KindInt, Env: "CLI_TEST_REPLICAS", Validate: InRange(1, 5)},
+			{Type: "force", Long: "force", Kind: KindBool, Choices: []string{"true"}},
+		},
+		Args: []*CommandArg{
+			{Name: "env", Required: true, Choices: []string{"dev", "prod"}},
+			{Name: "versions", Variadic: true, Validate: MatchRegexp(`v\d+`)},
+		},
+		Run: func(flags map[string]*ParsedCommandFlags, args []string) {},
+	})
+	tests := []struct {
+		name    string
+		env     string
+		line    string
+		wantErr string
+		target  error
+	}{
+		{name: "valid", line: "deploy --format=json --tags=a,b --replicas=3 --force prod v1 v2"},
+		{name: "flag choice", line: "deploy --format=xml dev", wantErr: `invalid flag value: --format: "xml" is not one of json, table`, target: ErrInvalidFlagValue},
+		{name: "strings choice", line: "deploy --tags=a,c dev", wantErr: `invalid flag value: --tags: "c" is not one of a, b`, target: ErrInvalidFlagValue},
+		{name: "flag validator", line: "deploy --replicas=9 dev", wantErr: "invalid flag value: --replicas: 9 is out of range [1, 5]", target: ErrInvalidFlagValue},
+		{name: "env validator", env: "0", line: "deploy dev", wantErr: "invalid flag value: --replicas: 0 is out of range [1, 5]", target: ErrInvalidFlagValue},
+		{name: "bool without value", line: "deploy --force dev"},
+		{name: "argument choice", line: "deploy stage", wantErr: `invalid argument <env>: "stage" is not one of dev, prod`, target: ErrUsage},
+		{name: "variadic validator", line: "deploy dev v1 latest", wantErr: `invalid argument [versions...]: "latest" does not match v\d+`, target: ErrUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.env != "" {
+				t.Setenv("CLI_TEST_REPLICAS", tt.env)
+			}
+			err := c.RunLine(context.Background(), tt.line)
+			if errText(err) != tt.wantErr {
+				t.Fatalf("cli.RunLine() error = %v, want %q", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, tt.target) {
+				t.Errorf("cli.RunLine() error = %v, want %v", err, tt.target)
+			}
+			if err != nil && ExitCode(err) != ExitUsage {
+				t.Errorf("ExitCode() = %d, want %d", ExitCode(err), ExitUsage)
+			}
+		})
+	}
+
+	var out strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+	if err := c.RunLine(ctx, "deploy --help"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  env        (one of dev|prod, required)\n",
+		"      --format=string   Output format (one of json|table)\n",
+		"      --tags=strings    (one of a|b)\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("help output does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
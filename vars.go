@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ErrUndefinedVariable is returned by expansion of an undefined variable with UndefinedVarError
+var ErrUndefinedVariable = errors.New("undefined variable")
+
+// UndefinedVarPolicy defines how an undefined variable is expanded
+type UndefinedVarPolicy int
+
+const (
+	UndefinedVarEmpty UndefinedVarPolicy = iota // expand to an empty string
+	UndefinedVarKeep                            // keep the reference, e.g. '$name', as is
+	UndefinedVarError                           // fail the command with ErrUndefinedVariable
+)
+
+// Sets how undefined variables are expanded, UndefinedVarEmpty by default
+func WithUndefinedVars(policy UndefinedVarPolicy) Option {
+	return func(c *cli) {
+		c.undefinedVars = policy
+	}
+}
+
+// Sets a session variable expanded in command lines as $name or ${name}
+func (c *cli) SetVar(name, value string) error {
+	if !isVarName(name) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	c.varsMu.Lock()
+	defer c.varsMu.Unlock()
+	c.vars[name] = value
+	return nil
+}
+
+// Returns a session variable and whether it is set
+func (c *cli) LookupVar(name string) (string, bool) {
+	c.varsMu.RLock()
+	defer c.varsMu.RUnlock()
+	value, ok := c.vars[name]
+	return value, ok
+}
+
+// expandWord joins the parts of w, expanding variables in unquoted and double-quoted parts
+// and running command substitutions. The result is a single argument even if values contain spaces.
+func (c *cli) expandWord(ctx context.Context, w word) (string, error) {
+	var b strings.Builder
+	for _, p := range w {
+		if p.subst {
+			out, err := c.substitute(ctx, p.text)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(out)
+			continue
+		}
+		if p.quote == '\'' {
+			b.WriteString(p.text)
+			continue
+		}
+		if err := c.expandVars(&b, p.text); err != nil {
+			return "", err
+		}
+	}
+	return b.String(), nil
+}
+
+func (c *cli) expandWords(ctx context.Context, words []word) ([]string, error) {
+	argv := make([]string, len(words))
+	for i, w := range words {
+		arg, err := c.expandWord(ctx, w)
+		if err != nil {
+			return nil, err
+		}
+		argv[i] = arg
+	}
+	return argv, nil
+}
+
+// expandVars writes text to b replacing $name and ${name}. A '$' not followed by a name is kept.
+func (c *cli) expandVars(b *strings.Builder, text string) error {
+	for {
+		i := strings.IndexByte(text, '$')
+		if i < 0 {
+			b.WriteString(text)
+			return nil
+		}
+		b.WriteString(text[:i])
+		text = text[i:]
+
+		var name, ref string
+		if strings.HasPrefix(text, "${") {
+			end := strings.IndexByte(text, '}')
+			if end < 0 {
+				return fmt.Errorf("unclosed %q", "${")
+			}
+			name, ref = text[2:end], text[:end+1]
+			if !isVarName(name) {
+				return fmt.Errorf("invalid variable name %q", name)
+			}
+		} else {
+			n := 1
+			for n < len(text) && isVarRune(rune(text[n]), n == 1) {
+				n++
+			}
+			name, ref = text[1:n], text[:n]
+		}
+		text = text[len(ref):]
+		if name == "" {
+			b.WriteString(ref)
+			continue
+		}
+
+		value, ok := c.LookupVar(name)
+		switch {
+		case ok:
+			b.WriteString(value)
+		case c.undefinedVars == UndefinedVarKeep:
+			b.WriteString(ref)
+		case c.undefinedVars == UndefinedVarError:
+			return fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+		}
+	}
+}
+
+func isVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !isVarRune(r, i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVarRune(r rune, first bool) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (!first && r >= '0' && r <= '9')
+}
+
+func (c *cli) setCommand() *Command {
+	return &Command{
+		Use: "set",
+		Desc: Description{
+			Short: "Sets or lists session variables",
+			Long:  "'set <name> <value>' sets a variable expanded as $name or ${name}, 'set' lists all variables.",
+		},
+		RunE: func(ctx context.Context, flags map[string]*ParsedCommandFlags, args []string) error {
+			if len(args) == 0 {
+				c.varsMu.RLock()
+				lines := make([]string, 0, len(c.vars))
+				for name, value := range c.vars {
+					lines = append(lines, name+"="+value+"\n")
+				}
+				c.varsMu.RUnlock()
+				sort.Strings(lines)
+				_, err := io.WriteString(StreamsFrom(ctx).Stdout, strings.Join(lines, ""))
+				return err
+			}
+			if len(args) != 2 {
+				return usagef("usage: set [<name> <value>]")
+			}
+			return c.SetVar(args[0], args[1])
+		},
+	}
+}
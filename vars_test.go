@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_cli_vars(t *testing.T) {
+	var gotArgs []string
+	newCli := func(opts ...Option) Cli {
+		c := NewCli(opts...)
+		c.AddCmd(&Command{
+			Use: "echo",
+			Run: func(flags map[string]*ParsedCommandFlags, args []string) {
+				gotArgs = args
+			},
+		})
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "plain and braced",
+			input: "set name 'big world'; echo $name ${name}s x$name.y",
+			want: []string{"big world", "big worlds", "xbig world.y"},
+		},
+		{
+			name: "double quotes expand, single quotes do not",
+			input: "set name world; echo \"hello $name\" 'hello $name' \"$\"name",
+			want: []string{"hello world", "hello $name", "$name"},
+		},
+		{
+			name: "dollar without name",
+			input: "echo $ a$ $1",
+			want: []string{"$", "a$", "$1"},
+		},
+		{
+			name: "undefined expands to empty",
+			input: "echo [$missing]",
+			want: []string{"[]"},
+		},
+		{
+			name: "undefined is kept",
+			opts: []Option{WithUndefinedVars(UndefinedVarKeep)},
+			input: "echo $missing ${missing}",
+			want: []string{"$missing", "${missing}"},
+		},
+		{
+			name: "undefined is an error",
+			opts: []Option{WithUndefinedVars(UndefinedVarError)},
+			input: "echo $missing",
+			wantErr: ErrUndefinedVariable,
+		},
+		{
+			name: "expanded when the command runs",
+			input: "set a 1; set b $a$a; echo $b",
+			want: []string{"11"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotArgs = nil
+			err := newCli(tt.opts...).RunLine(context.Background(), tt.input)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("cli.RunLine() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(gotArgs, tt.want) {
+				t.Errorf("args = %q, want %q", gotArgs, tt.want)
+			}
+		})
+	}
+}
+
+func Test_cli_setList(t *testing.T) {
+	c := NewCli()
+	c.SetVar("b", "2")
+	c.SetVar("a", "1 1")
+	if err := c.SetVar("1a", "x"); err == nil {
+		t.Errorf("cli.SetVar() must reject invalid names")
+	}
+	var out strings.Builder
+	ctx := ContextWithStreams(context.Background(), Streams{Stdout: &out})
+	if err := c.RunLine(ctx, "set"); err != nil {
+		t.Fatalf("cli.RunLine() error = %v", err)
+	}
+	if want := "a=1 1\nb=2\n"; out.String() != want {
+		t.Errorf("set output = %q, want %q", out.String(), want)
+	}
+}